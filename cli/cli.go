@@ -13,6 +13,12 @@ import (
 
 // Run the prisma CLI with given arguments
 func Run(arguments []string, output bool) error {
+	return RunWithEnv(arguments, nil, output)
+}
+
+// RunWithEnv runs the prisma CLI with given arguments and additional env vars in the form "KEY=value", e.g. to set
+// the datasource URL of a schema
+func RunWithEnv(arguments []string, env []string, output bool) error {
 	logger.Debug.Printf("running cli with args %+v", arguments)
 	// TODO respect initial PRISMA_<name>_BINARY env
 	// TODO optionally override CLI filepath using PRISMA_CLI_PATH
@@ -47,6 +53,8 @@ func Run(arguments []string, output bool) error {
 		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", engine.Env, value))
 	}
 
+	cmd.Env = append(cmd.Env, env...)
+
 	cmd.Stdin = os.Stdin
 
 	if output {
@@ -0,0 +1,258 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/steebchen/prisma-client-go/engine/protocol"
+	"github.com/steebchen/prisma-client-go/logger"
+)
+
+// DefaultBatchWindow is the default time to collect FindUnique queries before sending them as a batch
+const DefaultBatchWindow = time.Millisecond
+
+// DefaultMaxBatchSize is the default number of FindUnique queries after which a batch is sent right away
+const DefaultMaxBatchSize = 100
+
+// NewBatchingEngine returns an engine which collects concurrent FindUnique queries for the given window and sends
+// them to the query engine as a single non-transactional batch, which the query engine can combine into a single
+// database query. A batch is sent early once it contains maxSize queries.
+func NewBatchingEngine(e Engine, window time.Duration, maxSize int) *BatchingEngine {
+	if window <= 0 {
+		window = DefaultBatchWindow
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultMaxBatchSize
+	}
+	return &BatchingEngine{
+		Engine:  e,
+		window:  window,
+		maxSize: maxSize,
+		pending: make(map[batchKey]*pendingBatch),
+	}
+}
+
+// BatchingEngine batches concurrent FindUnique queries
+type BatchingEngine struct {
+	Engine
+
+	window  time.Duration
+	maxSize int
+
+	// pending contains the batches which are currently collected
+	pending map[batchKey]*pendingBatch
+
+	mu sync.Mutex
+}
+
+// batchKey groups queries which are sent to the same engine, e.g. the same tenant
+type batchKey struct {
+	tenant  string
+	primary bool
+}
+
+type pendingBatch struct {
+	items []*batchItem
+	timer *time.Timer
+}
+
+type batchItem struct {
+	ctx     context.Context
+	payload interface{}
+	into    interface{}
+	done    chan error
+
+	// cancelled is set when the caller stopped waiting for the result, so into must not be written anymore
+	cancelled bool
+	mu        sync.Mutex
+}
+
+// Do batches FindUnique queries and sends all other queries right away
+func (e *BatchingEngine) Do(ctx context.Context, payload interface{}, into interface{}) error {
+	if _, ok := TransactionID(ctx); ok || !isFindUnique(payload) {
+		return e.Engine.Do(ctx, payload, into)
+	}
+
+	item := &batchItem{
+		ctx:     ctx,
+		payload: payload,
+		into:    into,
+		done:    make(chan error, 1),
+	}
+	e.add(item)
+
+	select {
+	case err := <-item.done:
+		return err
+	case <-ctx.Done():
+		item.mu.Lock()
+		item.cancelled = true
+		item.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (e *BatchingEngine) add(item *batchItem) {
+	tenant, _ := Tenant(item.ctx)
+	key := batchKey{tenant: tenant, primary: IsPrimary(item.ctx)}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	batch, ok := e.pending[key]
+	if !ok {
+		batch = &pendingBatch{}
+		batch.timer = time.AfterFunc(e.window, func() {
+			e.flush(key, batch)
+		})
+		e.pending[key] = batch
+	}
+
+	batch.items = append(batch.items, item)
+
+	if len(batch.items) >= e.maxSize && batch.timer.Stop() {
+		delete(e.pending, key)
+		go e.flush(key, batch)
+	}
+}
+
+// flush sends a pending batch and delivers the results to the callers
+func (e *BatchingEngine) flush(key batchKey, batch *pendingBatch) {
+	e.mu.Lock()
+	if e.pending[key] == batch {
+		delete(e.pending, key)
+	}
+	items := batch.items
+	e.mu.Unlock()
+
+	if len(items) == 1 {
+		items[0].deliver(func() error {
+			return e.Engine.Do(items[0].ctx, items[0].payload, items[0].into)
+		})
+		return
+	}
+
+	logger.Debug.Printf("sending %d batched FindUnique queries", len(items))
+
+	ctx, cancel := batchContext(key, items)
+	defer cancel()
+
+	// the batch is sent on behalf of all callers, so it's traced in a span of its own which links their spans
+	links := make([]trace.Link, len(items))
+	for i, item := range items {
+		links[i] = trace.LinkFromContext(item.ctx)
+	}
+	ctx, span := e.Engine.TracerProvider().Tracer(TracerName).Start(ctx, "prisma:client:batch",
+		trace.WithLinks(links...),
+		trace.WithAttributes(
+			attribute.Int("prisma.batch_size", len(items)),
+		),
+	)
+
+	var response protocol.GQLBatchResponse
+	err := e.Engine.Batch(ctx, batchPayload(items), &response)
+	if err == nil && len(response.Errors) > 0 {
+		err = ResponseError(response.Errors[0])
+	}
+	if err == nil && len(response.Result) != len(items) {
+		err = fmt.Errorf("expected %d batch results but got %d", len(items), len(response.Result))
+	}
+	EndSpan(span, err)
+
+	for i, item := range items {
+		i, item := i, item
+		item.deliver(func() error {
+			if err != nil {
+				return err
+			}
+			result := response.Result[i]
+			if len(result.Errors) > 0 {
+				return ResponseError(result.Errors[0])
+			}
+			if err := json.Unmarshal(result.Data.Result, item.into); err != nil {
+				return fmt.Errorf("json data result unmarshal: %w", err)
+			}
+			return nil
+		})
+	}
+}
+
+// batchContext returns the context a batch is sent with. It isn't derived from any caller, so the batch is still sent
+// when some callers are cancelled, but it routes the batch like its callers and applies their earliest deadline.
+func batchContext(key batchKey, items []*batchItem) (context.Context, context.CancelFunc) {
+	ctx := context.Background()
+	if key.tenant != "" {
+		ctx = WithTenant(ctx, key.tenant)
+	}
+	if key.primary {
+		ctx = WithPrimary(ctx)
+	}
+
+	var deadline time.Time
+	for _, item := range items {
+		if d, ok := item.ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+			deadline = d
+		}
+	}
+	if deadline.IsZero() {
+		return ctx, func() {}
+	}
+	return context.WithDeadline(ctx, deadline)
+}
+
+// deliver writes the result unless the caller stopped waiting for it
+func (i *batchItem) deliver(fn func() error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.cancelled {
+		return
+	}
+
+	i.done <- fn()
+}
+
+// batchPayload builds a non-transactional batch of all queries
+func batchPayload(items []*batchItem) interface{} {
+	if _, ok := items[0].payload.(protocol.JSONRequest); ok {
+		requests := make([]protocol.JSONRequest, len(items))
+		for i, item := range items {
+			requests[i] = item.payload.(protocol.JSONRequest)
+		}
+		return protocol.JSONBatchRequest{Batch: requests}
+	}
+
+	requests := make([]protocol.GQLRequest, len(items))
+	for i, item := range items {
+		requests[i] = item.payload.(protocol.GQLRequest)
+	}
+	return protocol.GQLBatchRequest{Batch: requests}
+}
+
+// isFindUnique returns whether a payload is a single FindUnique query
+func isFindUnique(payload interface{}) bool {
+	switch p := payload.(type) {
+	case protocol.GQLRequest:
+		query := strings.TrimSpace(p.Query)
+		if !strings.HasPrefix(query, "query") {
+			return false
+		}
+		_, inner, ok := strings.Cut(query, "result: ")
+		if !ok {
+			return false
+		}
+		name, _, _ := strings.Cut(strings.TrimSpace(inner), " ")
+		name, _, _ = strings.Cut(name, "(")
+		return strings.HasPrefix(name, "findUnique") && !strings.HasSuffix(name, "OrThrow")
+	case protocol.JSONRequest:
+		return p.Action == "findUnique"
+	}
+	return false
+}
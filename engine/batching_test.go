@@ -0,0 +1,233 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/steebchen/prisma-client-go/engine/protocol"
+	"github.com/steebchen/prisma-client-go/runtime/types"
+)
+
+// batchRecorder answers FindUnique queries for the ids "a" and "b", and records all requests
+type batchRecorder struct {
+	Engine
+	requests []interface{}
+	// ctx is the context of the last batch
+	ctx context.Context
+	tp  trace.TracerProvider
+	mu  sync.Mutex
+}
+
+func (e *batchRecorder) TracerProvider() trace.TracerProvider {
+	if e.tp == nil {
+		return noop.NewTracerProvider()
+	}
+	return e.tp
+}
+
+func (e *batchRecorder) record(payload interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.requests = append(e.requests, payload)
+}
+
+func (e *batchRecorder) Do(ctx context.Context, payload interface{}, into interface{}) error {
+	e.record(payload)
+	return json.Unmarshal([]byte(`{"id":"single"}`), into)
+}
+
+func (e *batchRecorder) Batch(ctx context.Context, payload interface{}, into interface{}) error {
+	e.record(payload)
+	e.ctx = ctx
+	response := into.(*protocol.GQLBatchResponse)
+	for _, r := range payload.(protocol.GQLBatchRequest).Batch {
+		switch {
+		case strings.Contains(r.Query, `"a"`):
+			response.Result = append(response.Result, protocol.GQLResponse{Data: protocol.Data{Result: []byte(`{"id":"a"}`)}})
+		case strings.Contains(r.Query, `"b"`):
+			response.Result = append(response.Result, protocol.GQLResponse{Data: protocol.Data{Result: []byte(`{"id":"b"}`)}})
+		case strings.Contains(r.Query, `"error"`):
+			response.Result = append(response.Result, protocol.GQLResponse{Errors: []protocol.GQLError{{
+				UserFacingError: &protocol.UserFacingError{ErrorCode: "P2034", Message: "conflict"},
+			}}})
+		default:
+			response.Result = append(response.Result, protocol.GQLResponse{Data: protocol.Data{Result: []byte(`null`)}})
+		}
+	}
+	return nil
+}
+
+func findUnique(id string) protocol.GQLRequest {
+	return protocol.GQLRequest{Query: fmt.Sprintf(`query {result: findUniqueUser(where: {id: %q}) {id}}`, id)}
+}
+
+type user struct {
+	ID string `json:"id"`
+}
+
+func TestBatchingEngine_Do(t *testing.T) {
+	inner := &batchRecorder{}
+	e := NewBatchingEngine(inner, 10*time.Millisecond, 0)
+
+	ids := []string{"a", "b", "missing", "error"}
+	results := make([]*user, len(ids))
+	errs := make([]error, len(ids))
+
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			errs[i] = e.Do(context.Background(), findUnique(id), &results[i])
+		}(i, id)
+	}
+	wg.Wait()
+
+	assert.Len(t, inner.requests, 1)
+	assert.Len(t, inner.requests[0].(protocol.GQLBatchRequest).Batch, 4)
+	assert.Nil(t, inner.requests[0].(protocol.GQLBatchRequest).Transaction)
+
+	assert.NoError(t, errs[0])
+	assert.Equal(t, "a", results[0].ID)
+	assert.NoError(t, errs[1])
+	assert.Equal(t, "b", results[1].ID)
+	assert.NoError(t, errs[2])
+	assert.Nil(t, results[2])
+	_, isConflict := types.As[types.ErrTransactionConflict](errs[3])
+	assert.True(t, isConflict)
+}
+
+func TestBatchingEngine_passThrough(t *testing.T) {
+	inner := &batchRecorder{}
+	e := NewBatchingEngine(inner, time.Hour, 0)
+
+	var u user
+	assert.NoError(t, e.Do(context.Background(), protocol.GQLRequest{Query: `query {result: findManyUser {id}}`}, &u))
+	assert.NoError(t, e.Do(WithTransactionID(context.Background(), "tx"), findUnique("a"), &u))
+
+	assert.Len(t, inner.requests, 2)
+}
+
+func TestBatchingEngine_single(t *testing.T) {
+	inner := &batchRecorder{}
+	e := NewBatchingEngine(inner, time.Millisecond, 0)
+
+	var u user
+	assert.NoError(t, e.Do(context.Background(), findUnique("a"), &u))
+
+	// a single query is sent without a batch
+	assert.Equal(t, []interface{}{findUnique("a")}, inner.requests)
+	assert.Equal(t, "single", u.ID)
+}
+
+func TestBatchingEngine_maxSize(t *testing.T) {
+	inner := &batchRecorder{}
+	e := NewBatchingEngine(inner, time.Hour, 2)
+
+	var wg sync.WaitGroup
+	for _, id := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			var u *user
+			assert.NoError(t, e.Do(context.Background(), findUnique(id), &u))
+			assert.Equal(t, id, u.ID)
+		}(id)
+	}
+	wg.Wait()
+
+	assert.Len(t, inner.requests, 1)
+}
+
+func TestBatchingEngine_tenants(t *testing.T) {
+	inner := &batchRecorder{}
+	e := NewBatchingEngine(inner, 10*time.Millisecond, 0)
+
+	var wg sync.WaitGroup
+	for _, tenant := range []string{"x", "x", "y"} {
+		wg.Add(1)
+		go func(tenant string) {
+			defer wg.Done()
+			var u *user
+			assert.NoError(t, e.Do(WithTenant(context.Background(), tenant), findUnique("a"), &u))
+		}(tenant)
+	}
+	wg.Wait()
+
+	// one batch for tenant x, and a single query for tenant y
+	assert.Len(t, inner.requests, 2)
+}
+
+func TestBatchingEngine_context(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	inner := &batchRecorder{tp: tp}
+	e := NewBatchingEngine(inner, 10*time.Millisecond, 0)
+
+	deadline := time.Now().Add(time.Minute)
+	callers := make([]trace.SpanContext, 2)
+
+	var wg sync.WaitGroup
+	for i, id := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			ctx, span := tp.Tracer("test").Start(WithTenant(context.Background(), "x"), "caller")
+			defer span.End()
+			callers[i] = span.SpanContext()
+			if i == 1 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithDeadline(ctx, deadline)
+				defer cancel()
+			}
+			var u *user
+			assert.NoError(t, e.Do(ctx, findUnique(id), &u))
+		}(i, id)
+	}
+	wg.Wait()
+
+	// the batch is routed like its callers and applies the earliest deadline of them
+	assert.Len(t, inner.requests, 1)
+	tenant, _ := Tenant(inner.ctx)
+	assert.Equal(t, "x", tenant)
+	got, ok := inner.ctx.Deadline()
+	assert.True(t, ok)
+	assert.Equal(t, deadline, got)
+
+	// the batch is traced in a span of its own which links the spans of all callers
+	var batch sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "prisma:client:batch" {
+			batch = span
+		}
+	}
+	if !assert.NotNil(t, batch) {
+		return
+	}
+	assert.False(t, batch.Parent().IsValid())
+	var linked []trace.SpanContext
+	for _, link := range batch.Links() {
+		linked = append(linked, link.SpanContext)
+	}
+	assert.ElementsMatch(t, callers, linked)
+}
+
+func Test_isFindUnique(t *testing.T) {
+	assert.True(t, isFindUnique(findUnique("a")))
+	assert.True(t, isFindUnique(protocol.JSONRequest{Action: "findUnique"}))
+	assert.False(t, isFindUnique(protocol.GQLRequest{Query: `query {result: findUniqueUserOrThrow {id}}`}))
+	assert.False(t, isFindUnique(protocol.GQLRequest{Query: `query {result: findFirstUser {id}}`}))
+	assert.False(t, isFindUnique(protocol.GQLRequest{Query: `mutation {result: deleteOneUser {id}}`}))
+	assert.False(t, isFindUnique(protocol.JSONRequest{Action: "findMany"}))
+}
@@ -14,6 +14,20 @@ import (
 
 var errNotFound = fmt.Errorf("not found; re-upload schema")
 
+// transportError is returned when a request failed before the query engine responded, e.g. because the connection
+// was refused or closed
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string {
+	return e.err.Error()
+}
+
+func (e *transportError) Unwrap() error {
+	return e.err
+}
+
 func request(ctx context.Context, client *http.Client, method string, url string, payload []byte, apply func(*http.Request)) ([]byte, error) {
 	if logger.Enabled {
 		logger.Debug.Printf("prisma engine payload: `%s`", payload)
@@ -31,7 +45,7 @@ func request(ctx context.Context, client *http.Client, method string, url string
 	startReq := time.Now()
 	rawResponse, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("raw post: %w", err)
+		return nil, fmt.Errorf("raw post: %w", &transportError{err})
 	}
 	defer func() {
 		if err := rawResponse.Body.Close(); err != nil {
@@ -43,7 +57,7 @@ func request(ctx context.Context, client *http.Client, method string, url string
 
 	responseBody, err := io.ReadAll(rawResponse.Body)
 	if err != nil {
-		return nil, fmt.Errorf("raw read: %w", err)
+		return nil, fmt.Errorf("raw read: %w", &transportError{err})
 	}
 
 	if rawResponse.StatusCode == http.StatusNotFound {
@@ -2,6 +2,10 @@ package engine
 
 import (
 	"context"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/steebchen/prisma-client-go/engine/protocol"
 )
 
 type Engine interface {
@@ -9,5 +13,17 @@ type Engine interface {
 	Disconnect() error
 	Do(ctx context.Context, payload interface{}, into interface{}) error
 	Batch(ctx context.Context, payload interface{}, into interface{}) error
+	StartTx(ctx context.Context, options protocol.TransactionStartRequest) (string, error)
+	CommitTx(ctx context.Context, id string) error
+	RollbackTx(ctx context.Context, id string) error
+	Metrics(ctx context.Context, format MetricsFormat) ([]byte, error)
+	// Ping checks that the engine is alive and responds to requests
+	Ping(ctx context.Context) error
+	// Status returns the state of the engine
+	Status() Status
 	Name() string
+	// Protocol returns the wire format which queries are sent in
+	Protocol() Protocol
+	// TracerProvider returns the provider which is used to trace queries
+	TracerProvider() trace.TracerProvider
 }
@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"testing"
+)
+
+// fakeEngineEnv makes the test binary act as a query engine when it's started as one, see useFakeEngine
+const fakeEngineEnv = "PRISMA_CLIENT_GO_FAKE_ENGINE"
+
+func init() {
+	if os.Getenv(fakeEngineEnv) == "1" {
+		runFakeEngine()
+		os.Exit(0)
+	}
+}
+
+// useFakeEngine makes query engines started by the test run the test binary as a fake query engine, which answers
+// every query with the url of the database it would connect to, and serves metrics if they are enabled
+func useFakeEngine(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires interrupt signals")
+	}
+
+	t.Setenv(fakeEngineEnv, "1")
+	t.Setenv("PRISMA_QUERY_ENGINE_BINARY", os.Args[0])
+}
+
+func runFakeEngine() {
+	if len(os.Args) > 1 && os.Args[1] == "--version" {
+		fmt.Println("query-engine fake")
+		return
+	}
+
+	var port string
+	for i, arg := range os.Args {
+		if arg == "-p" && i+1 < len(os.Args) {
+			port = os.Args[i+1]
+		}
+	}
+
+	// the url of the datasource overrides the url of the schema, like in the query engine
+	url := os.Getenv("DATABASE_URL")
+	if raw, err := base64.URLEncoding.DecodeString(os.Getenv("OVERWRITE_DATASOURCES")); err == nil {
+		var overrides []DatasourceOverride
+		if err := json.Unmarshal(raw, &overrides); err == nil && len(overrides) > 0 {
+			url = overrides[0].URL
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		for _, arg := range os.Args {
+			if arg == "--enable-metrics" {
+				_, _ = w.Write([]byte(`{"counters":[],"gauges":[],"histograms":[]}`))
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		result, _ := json.Marshal(map[string]string{"url": url})
+		_, _ = w.Write([]byte(`{"data":{"result":` + string(result) + `}}`))
+	})
+
+	if err := http.ListenAndServe("localhost:"+port, mux); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// State describes whether an engine can serve requests
+type State string
+
+const (
+	// StateNotConnected is the state before Connect() was called
+	StateNotConnected State = "not-connected"
+	// StateConnected is the state after Connect() succeeded
+	StateConnected State = "connected"
+	// StateCrashed is the state while a crashed query engine is restarted, or after it could not be restarted
+	StateCrashed State = "crashed"
+	// StateDisconnected is the state after Disconnect() was called
+	StateDisconnected State = "disconnected"
+)
+
+// Status describes the state of an engine
+type Status struct {
+	State State
+	// PID is the process id of the query engine, or 0 if the engine doesn't run a local process
+	PID int
+	// Uptime is the time since the query engine process was started or last restarted
+	Uptime time.Duration
+	// Version is the query engine version, if known
+	Version string
+}
+
+// Ping checks that the query engine process is alive and responds to requests.
+// It does not send a query to the database.
+func (e *QueryEngine) Ping(ctx context.Context) error {
+	e.mu.RLock()
+	crashErr := e.crashErr
+	e.mu.RUnlock()
+
+	if crashErr != nil {
+		return crashErr
+	}
+
+	if status := e.Status(); status.State != StateConnected {
+		return fmt.Errorf("query engine is %s", status.State)
+	}
+
+	body, err := e.Request(ctx, "GET", "/status", map[string]interface{}{}, true)
+	if err != nil {
+		return fmt.Errorf("status request: %w", err)
+	}
+
+	var response struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("status response unmarshal: %w", err)
+	}
+
+	if response.Status != "ok" {
+		return fmt.Errorf("unexpected query engine status: %s", response.Status)
+	}
+
+	return nil
+}
+
+// Status returns the state, process id, uptime and version of the query engine
+func (e *QueryEngine) Status() Status {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	status := Status{
+		State:   StateNotConnected,
+		Version: e.version,
+	}
+
+	switch {
+	case e.disconnected:
+		status.State = StateDisconnected
+	case e.crashErr != nil:
+		status.State = StateCrashed
+	case e.connected:
+		status.State = StateConnected
+	}
+
+	// externally managed query engines don't have a local process
+	if status.State == StateConnected && e.cmd != nil && e.cmd.Process != nil {
+		status.PID = e.cmd.Process.Pid
+		status.Uptime = time.Since(e.startedAt)
+	}
+
+	return status
+}
@@ -0,0 +1,34 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryEngine_Ping(t *testing.T) {
+	s := sidecarServer(t, `{"status":"ok"}`)
+	e := NewQueryEngine("", false, "", "", WithEngineURL(s.URL))
+
+	assert.Equal(t, Status{State: StateNotConnected}, e.Status())
+	assert.ErrorContains(t, e.Ping(context.Background()), "query engine is not-connected")
+
+	assert.NoError(t, e.Connect())
+	// externally managed query engines have no local process
+	assert.Equal(t, Status{State: StateConnected}, e.Status())
+	assert.NoError(t, e.Ping(context.Background()))
+
+	e.mu.Lock()
+	e.crashErr = &EngineCrashedError{Message: "boom"}
+	e.mu.Unlock()
+	assert.Equal(t, StateCrashed, e.Status().State)
+	assert.ErrorContains(t, e.Ping(context.Background()), "query engine crashed: boom")
+
+	e.mu.Lock()
+	e.crashErr = nil
+	e.mu.Unlock()
+	assert.NoError(t, e.Disconnect())
+	assert.Equal(t, StateDisconnected, e.Status().State)
+	assert.ErrorContains(t, e.Ping(context.Background()), "query engine is disconnected")
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"os/exec"
 	"path"
@@ -21,14 +23,9 @@ import (
 )
 
 func (e *QueryEngine) Connect() error {
-	e.closed = make(chan interface{})
-
-	success := false
-	defer func() {
-		if !success {
-			close(e.closed)
-		}
-	}()
+	if e.engineURL != "" {
+		return e.connectRemote()
+	}
 
 	logger.Debug.Printf("ensure query engine binary...")
 
@@ -38,9 +35,15 @@ func (e *QueryEngine) Connect() error {
 
 	startEngine := time.Now()
 
-	file, err := e.ensure()
-	if err != nil {
-		return fmt.Errorf("ensure: %w", err)
+	// the binary may have already been resolved by a previous connect or by another engine, e.g. of another tenant
+	file := e.file
+	if file == "" {
+		var err error
+		file, err = e.ensure()
+		if err != nil {
+			return fmt.Errorf("ensure: %w", err)
+		}
+		e.file = file
 	}
 
 	if err := e.spawn(file); err != nil {
@@ -49,43 +52,67 @@ func (e *QueryEngine) Connect() error {
 
 	logger.Debug.Printf("connecting took %s", time.Since(startEngine))
 
-	if e.lastEngineError != "" {
-		return fmt.Errorf("query engine errored: %w", fmt.Errorf(e.lastEngineError))
-	}
-
+	e.mu.Lock()
 	e.connected = true
-	success = true
+	e.mu.Unlock()
 
 	logger.Debug.Printf("connected.")
 
+	e.emit(Event{Type: EventConnected})
+
 	return nil
 }
 
 func (e *QueryEngine) Disconnect() error {
 	e.mu.Lock()
+	if !e.disconnected {
+		close(e.stop)
+	}
 	e.disconnected = true
+	cmd := e.cmd
+	exited := e.exited
+	crashed := e.crashErr != nil
+	ready := e.ready
 	e.mu.Unlock()
 	logger.Debug.Printf("disconnecting...")
 
+	// a crashed query engine is being restarted; restarting stops and kills a process which was started meanwhile,
+	// so no process is left afterwards
+	if crashed {
+		<-ready
+		logger.Debug.Printf("disconnected.")
+		return nil
+	}
+
+	// externally managed query engines keep running
+	if cmd == nil {
+		logger.Debug.Printf("disconnected.")
+		return nil
+	}
+
 	if platform.Name() == "windows" {
-		if err := e.cmd.Process.Kill(); err != nil {
+		if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
 			return fmt.Errorf("kill process: %w", err)
 		}
 		return nil
 	}
 
-	if err := e.cmd.Process.Signal(os.Interrupt); err != nil {
+	if err := cmd.Process.Signal(os.Interrupt); err != nil && !errors.Is(err, os.ErrProcessDone) {
 		return fmt.Errorf("send signal: %w", err)
 	}
 
-	if err := e.cmd.Wait(); err != nil {
-		if err.Error() != "signal: interrupt" {
-			return fmt.Errorf("wait for process: %w", err)
+	<-exited
+
+	e.mu.RLock()
+	exitErr := e.exitErr
+	e.mu.RUnlock()
+
+	if exitErr != nil {
+		if exitErr.Error() != "signal: interrupt" {
+			return fmt.Errorf("wait for process: %w", exitErr)
 		}
 	}
 
-	close(e.closed)
-
 	logger.Debug.Printf("disconnected.")
 	return nil
 }
@@ -175,7 +202,9 @@ func (e *QueryEngine) ensure() (string, error) {
 	}
 	logger.Debug.Printf("version check took %s", time.Since(startVersion))
 
-	if v := strings.TrimSpace(strings.Replace(string(out), "query-engine", "", 1)); binaries.EngineVersion != v {
+	v := strings.TrimSpace(strings.Replace(string(out), "query-engine", "", 1))
+	e.version = v
+	if binaries.EngineVersion != v {
 		note := "Did you forget to run `go run github.com/steebchen/prisma-client-go generate`?"
 		msg := fmt.Errorf("expected query engine version `%s` but got `%s`\n%s", binaries.EngineVersion, v, note)
 		if forceVersion {
@@ -204,8 +233,10 @@ func (e *QueryEngine) GetEncodedDatasources() (string, error) {
 		return "", fmt.Errorf("unmarshal datasources: %w", err)
 	}
 
+	// the datasource url is also overridden if the schema reads it from an env var, so that multiple engines with
+	// different urls can be started in one process, e.g. for read replicas or tenants
 	for i := range datasources {
-		if val := datasources[i].URL.Value; val != "" {
+		if datasources[i].URL.Value != "" || e.datasourceURL != "" {
 			overrides = append(overrides, DatasourceOverride{
 				Name: datasources[i].Name.String(),
 				URL:  e.datasourceURL,
@@ -228,34 +259,101 @@ func (e *QueryEngine) GetEncodedDatasources() (string, error) {
 }
 
 func (e *QueryEngine) spawn(file string) error {
-	port, err := getPort()
-	if err != nil {
-		return fmt.Errorf("get free port: %w", err)
+	if e.transport == TransportUnix {
+		err := e.start(file, TransportUnix)
+		if err == nil {
+			return nil
+		}
+
+		// don't start another process after Disconnect() was called
+		select {
+		case <-e.stop:
+			return err
+		default:
+		}
+
+		// only this attempt falls back, so a restart tries the unix socket again
+		logger.Info.Printf("could not start query engine on a unix socket, falling back to tcp: %s", err)
+
+		e.mu.Lock()
+		e.lastEngineError = ""
+		e.panicErr = nil
+		e.mu.Unlock()
 	}
 
-	logger.Debug.Printf("running query-engine on port %s", port)
+	return e.start(file, TransportTCP)
+}
 
-	e.httpURL = "http://localhost:" + port
+func (e *QueryEngine) start(file string, transport Transport) error {
+	var args []string
+	var httpURL string
+	var client *http.Client
+	var socket string
 
-	e.cmd = exec.Command(file, "-p", port, "--enable-raw-queries")
+	// the socket is removed when the process exits, or here if the process can't be started
+	started := false
+	defer func() {
+		if !started {
+			removeSocket(socket)
+		}
+	}()
 
-	e.cmd.SysProcAttr = getSysProcAttr()
+	switch transport {
+	case TransportUnix:
+		var err error
+		socket, err = getSocketPath()
+		if err != nil {
+			return fmt.Errorf("get socket path: %w", err)
+		}
+
+		logger.Debug.Printf("running query-engine on unix socket %s", socket)
+
+		args = []string{"--unix-path", socket}
+		// the host is ignored as the http client always dials the socket
+		httpURL = "http://localhost"
+		client = newUnixClient(socket)
+	default:
+		port, err := getPort()
+		if err != nil {
+			return fmt.Errorf("get free port: %w", err)
+		}
+
+		logger.Debug.Printf("running query-engine on port %s", port)
+
+		args = []string{"-p", port}
+		httpURL = "http://localhost:" + port
+		client = &http.Client{}
+	}
+
+	args = append(args, "--enable-raw-queries")
 
-	e.cmd.Stdout = os.Stdout
+	if e.metrics {
+		args = append(args, "--enable-metrics")
+	}
+
+	if e.tracerProvider != nil {
+		// return the engine spans in the response, so they can be emitted by the client's tracer provider
+		args = append(args, "--enable-open-telemetry", "--enable-telemetry-in-response")
+	}
+
+	cmd := exec.Command(file, args...)
+
+	cmd.SysProcAttr = getSysProcAttr()
 
-	e.onEngineError = make(chan string)
+	cmd.Stdout = os.Stdout
 
-	if err := e.streamStderr(e.cmd, e.onEngineError); err != nil {
+	stderrDone, err := e.streamStderr(cmd)
+	if err != nil {
 		return fmt.Errorf("setup stream: %w", err)
 	}
 
-	e.cmd.Env = append(
+	cmd.Env = append(
 		os.Environ(),
 		"PRISMA_DML="+e.Schema,
 		"RUST_LOG=error",
 		"RUST_LOG_FORMAT=json",
 		"PRISMA_CLIENT_ENGINE_TYPE=binary",
-		"PRISMA_ENGINE_PROTOCOL=graphql",
+		"PRISMA_ENGINE_PROTOCOL="+string(e.engineProtocol),
 	)
 
 	encDS, err := e.GetEncodedDatasources()
@@ -264,16 +362,17 @@ func (e *QueryEngine) spawn(file string) error {
 	}
 
 	if encDS != "" {
-		e.cmd.Env = append(
-			e.cmd.Env,
+		cmd.Env = append(
+			cmd.Env,
 			"OVERWRITE_DATASOURCES="+encDS,
 		)
 	}
 
-	// TODO fine tune this using log levels
+	cmd.Env = append(cmd.Env, e.logEnv()...)
+
 	if logger.Enabled {
-		e.cmd.Env = append(
-			e.cmd.Env,
+		cmd.Env = append(
+			cmd.Env,
 			"PRISMA_LOG_QUERIES=y",
 			"RUST_LOG=info",
 		)
@@ -281,22 +380,71 @@ func (e *QueryEngine) spawn(file string) error {
 
 	logger.Debug.Printf("starting engine...")
 
-	if err := e.cmd.Start(); err != nil {
+	if err := cmd.Start(); err != nil {
 		return fmt.Errorf("start command: %w", err)
 	}
+	started = true
+
+	exited := make(chan struct{})
+
+	e.mu.Lock()
+	e.cmd = cmd
+	e.exited = exited
+	e.httpURL = httpURL
+	e.http = client
+	e.socket = socket
+	e.mu.Unlock()
+
+	go e.watch(cmd, exited, socket, stderrDone)
 
 	logger.Debug.Printf("connecting to engine...")
 
-	// send a basic readiness healthcheck and retry if unsuccessful
+	if err := e.awaitReadiness(exited); err != nil {
+		// make sure the process doesn't keep running when it's not reachable, and wait until its output was read,
+		// so it doesn't affect the next attempt
+		_ = cmd.Process.Kill()
+		<-exited
+		return err
+	}
+
+	e.mu.Lock()
+	e.startedAt = time.Now()
+	e.mu.Unlock()
+
+	return nil
+}
+
+// awaitReadiness sends a basic readiness healthcheck and retries if unsuccessful
+func (e *QueryEngine) awaitReadiness(exited chan struct{}) error {
 	var connectErr error
 	for i := 0; i < 100; i++ {
 		e.mu.Lock()
+		lastEngineError := e.lastEngineError
+		panicErr := e.takePanic()
+		e.mu.Unlock()
+
 		// return an error early if an engine error already happened
-		if e.lastEngineError != "" {
+		if panicErr != nil {
+			return fmt.Errorf("query engine errored: %w", panicErr)
+		}
+		if lastEngineError != "" {
+			return fmt.Errorf("query engine errored: %w", fmt.Errorf(lastEngineError))
+		}
+
+		select {
+		case <-exited:
+			e.mu.Lock()
+			exitErr := e.exitErr
+			panicErr := e.takePanic()
 			e.mu.Unlock()
-			return fmt.Errorf("query engine errored: %w", fmt.Errorf(e.lastEngineError))
+			if panicErr != nil {
+				return fmt.Errorf("query engine exited: %w", panicErr)
+			}
+			return fmt.Errorf("query engine exited: %w", exitErr)
+		case <-e.stop:
+			return fmt.Errorf("client was disconnected while starting the query engine")
+		default:
 		}
-		e.mu.Unlock()
 
 		body, err := e.Request(context.Background(), "GET", "/status", map[string]interface{}{}, false)
 		if err != nil {
@@ -0,0 +1,165 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogLevel describes the level of a query engine log event
+type LogLevel string
+
+const (
+	// LogLevelQuery is used for executed database queries
+	LogLevelQuery LogLevel = "query"
+	LogLevelInfo  LogLevel = "info"
+	LogLevelWarn  LogLevel = "warn"
+	LogLevelError LogLevel = "error"
+)
+
+// LogEvent is a log event of the query engine
+type LogEvent struct {
+	Level     LogLevel
+	Timestamp time.Time
+	Message   string
+	// Target contains the module of the query engine which emitted the event
+	Target string
+
+	// Query contains the SQL text or the MongoDB command of query events
+	Query string
+	// Params contains the query parameters of query events as a JSON array
+	Params string
+	// Duration contains the execution time of query events
+	Duration time.Duration
+
+	// Fields contains all fields of the event as emitted by the query engine
+	Fields map[string]interface{}
+}
+
+// WithLogHandler registers a handler for query engine log events. If no levels are given, the handler receives
+// events of all levels.
+func WithLogHandler(handler func(LogEvent), levels ...LogLevel) QueryEngineOption {
+	return func(e *QueryEngine) {
+		e.onLog = handler
+		e.logLevels = make(map[LogLevel]bool)
+		for _, level := range levels {
+			e.logLevels[level] = true
+		}
+	}
+}
+
+// WithSlowQueryThreshold only emits query log events of queries which took at least the given duration
+func WithSlowQueryThreshold(d time.Duration) QueryEngineOption {
+	return func(e *QueryEngine) {
+		e.slowQueryThreshold = d
+	}
+}
+
+// subscribed returns whether the log handler receives events of the given level
+func (e *QueryEngine) subscribed(level LogLevel) bool {
+	if e.onLog == nil {
+		return false
+	}
+	return len(e.logLevels) == 0 || e.logLevels[level]
+}
+
+// logEnv returns the environment variables which configure the query engine log output
+func (e *QueryEngine) logEnv() []string {
+	switch {
+	case e.subscribed(LogLevelQuery):
+		return []string{"PRISMA_LOG_QUERIES=y", "RUST_LOG=info"}
+	case e.subscribed(LogLevelInfo):
+		return []string{"RUST_LOG=info"}
+	case e.subscribed(LogLevelWarn):
+		return []string{"RUST_LOG=warn"}
+	}
+	return nil
+}
+
+// handleLog sends a query engine log line to the log handler and returns whether it was handled
+func (e *QueryEngine) handleLog(line []byte) bool {
+	if e.onLog == nil {
+		return false
+	}
+
+	event, ok := parseLogEvent(line)
+	if !ok {
+		return false
+	}
+
+	if !e.subscribed(event.Level) {
+		return true
+	}
+
+	if event.Level == LogLevelQuery && event.Duration < e.slowQueryThreshold {
+		return true
+	}
+
+	e.onLog(event)
+	return true
+}
+
+type logLine struct {
+	Timestamp string                 `json:"timestamp"`
+	Level     string                 `json:"level"`
+	Target    string                 `json:"target"`
+	Fields    map[string]interface{} `json:"fields"`
+}
+
+// parseLogEvent parses a query engine log line, e.g.
+// {"timestamp":"...","level":"INFO","fields":{"query":"SELECT 1","params":"[]","duration_ms":1,"item_type":"query"},"target":"quaint::connector::metrics"}
+func parseLogEvent(line []byte) (LogEvent, bool) {
+	var l logLine
+	if err := json.Unmarshal(line, &l); err != nil || l.Level == "" {
+		return LogEvent{}, false
+	}
+
+	event := LogEvent{
+		Target: l.Target,
+		Fields: l.Fields,
+	}
+
+	event.Timestamp, _ = time.Parse(time.RFC3339Nano, l.Timestamp)
+	event.Message, _ = l.Fields["message"].(string)
+
+	switch strings.ToUpper(l.Level) {
+	case "ERROR":
+		event.Level = LogLevelError
+	case "WARN":
+		event.Level = LogLevelWarn
+	default:
+		event.Level = LogLevelInfo
+	}
+
+	if query, ok := l.Fields["query"].(string); ok && (l.Fields["item_type"] == "query" || l.Fields["is_query"] == true) {
+		event.Level = LogLevelQuery
+		event.Query = query
+		event.Params, _ = l.Fields["params"].(string)
+		event.Duration = parseDurationMs(l.Fields["duration_ms"])
+	}
+
+	return event, true
+}
+
+// parseDurationMs parses a duration in milliseconds, which the query engine sends as a number or a string
+func parseDurationMs(v interface{}) time.Duration {
+	var ms float64
+	switch d := v.(type) {
+	case float64:
+		ms = d
+	case string:
+		ms, _ = strconv.ParseFloat(d, 64)
+	default:
+		return 0
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+func (e LogEvent) String() string {
+	if e.Level == LogLevelQuery {
+		return fmt.Sprintf("%s %s %s (%s)", e.Level, e.Query, e.Params, e.Duration)
+	}
+	return fmt.Sprintf("%s %s", e.Level, e.Message)
+}
@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseLogEvent(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		want   LogEvent
+		wantOk bool
+	}{{
+		name: "query",
+		line: `{"timestamp":"2023-01-01T00:00:00.5Z","level":"INFO","fields":{"query":"SELECT 1","params":"[1]","duration_ms":12,"item_type":"query","is_query":true,"result":"success"},"target":"quaint::connector::metrics"}`,
+		want: LogEvent{
+			Level:     LogLevelQuery,
+			Timestamp: time.Date(2023, 1, 1, 0, 0, 0, 500_000_000, time.UTC),
+			Target:    "quaint::connector::metrics",
+			Query:     "SELECT 1",
+			Params:    "[1]",
+			Duration:  12 * time.Millisecond,
+		},
+		wantOk: true,
+	}, {
+		name: "query with string duration",
+		line: `{"level":"INFO","fields":{"query":"SELECT 1","params":"[]","duration_ms":"3","item_type":"query"},"target":"quaint::connector::metrics"}`,
+		want: LogEvent{
+			Level:    LogLevelQuery,
+			Target:   "quaint::connector::metrics",
+			Query:    "SELECT 1",
+			Params:   "[]",
+			Duration: 3 * time.Millisecond,
+		},
+		wantOk: true,
+	}, {
+		name: "info",
+		line: `{"timestamp":"2023-01-01T00:00:00Z","level":"INFO","fields":{"message":"Started query engine http server on http://127.0.0.1:4466"},"target":"query_engine::server"}`,
+		want: LogEvent{
+			Level:     LogLevelInfo,
+			Timestamp: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			Message:   "Started query engine http server on http://127.0.0.1:4466",
+			Target:    "query_engine::server",
+		},
+		wantOk: true,
+	}, {
+		name: "warn",
+		line: `{"level":"WARN","fields":{"message":"slow"},"target":"a"}`,
+		want: LogEvent{
+			Level:   LogLevelWarn,
+			Message: "slow",
+			Target:  "a",
+		},
+		wantOk: true,
+	}, {
+		name: "error",
+		line: `{"level":"ERROR","fields":{"message":"connection lost"},"target":"a"}`,
+		want: LogEvent{
+			Level:   LogLevelError,
+			Message: "connection lost",
+			Target:  "a",
+		},
+		wantOk: true,
+	}, {
+		name:   "engine error message",
+		line:   `{"is_panic":false,"message":"could not connect"}`,
+		wantOk: false,
+	}, {
+		name:   "invalid json",
+		line:   `not json`,
+		wantOk: false,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseLogEvent([]byte(tt.line))
+			assert.Equal(t, tt.wantOk, ok)
+			if !ok {
+				return
+			}
+			got.Fields = nil
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestQueryEngine_handleLog(t *testing.T) {
+	fast := `{"level":"INFO","fields":{"query":"SELECT 1","params":"[]","duration_ms":1,"item_type":"query"},"target":"a"}`
+	slow := `{"level":"INFO","fields":{"query":"SELECT 2","params":"[]","duration_ms":100,"item_type":"query"},"target":"a"}`
+	info := `{"level":"INFO","fields":{"message":"info"},"target":"a"}`
+	warn := `{"level":"WARN","fields":{"message":"warn"},"target":"a"}`
+
+	tests := []struct {
+		name      string
+		levels    []LogLevel
+		threshold time.Duration
+		want      []string
+	}{{
+		name: "all levels",
+		want: []string{"SELECT 1", "SELECT 2", "info", "warn"},
+	}, {
+		name:   "selected levels",
+		levels: []LogLevel{LogLevelQuery, LogLevelWarn},
+		want:   []string{"SELECT 1", "SELECT 2", "warn"},
+	}, {
+		name:      "slow query threshold",
+		threshold: 50 * time.Millisecond,
+		want:      []string{"SELECT 2", "info", "warn"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			handler := func(event LogEvent) {
+				if event.Level == LogLevelQuery {
+					got = append(got, event.Query)
+				} else {
+					got = append(got, event.Message)
+				}
+			}
+
+			e := NewQueryEngine("", false, "", "",
+				WithLogHandler(handler, tt.levels...),
+				WithSlowQueryThreshold(tt.threshold),
+			)
+
+			for _, line := range []string{fast, slow, info, warn} {
+				assert.True(t, e.handleLog([]byte(line)))
+			}
+			assert.False(t, e.handleLog([]byte(`{"is_panic":true,"message":"panic"}`)))
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestQueryEngine_logEnv(t *testing.T) {
+	handler := func(LogEvent) {}
+	tests := []struct {
+		name   string
+		option QueryEngineOption
+		want   []string
+	}{{
+		name:   "no handler",
+		option: WithSlowQueryThreshold(time.Second),
+		want:   nil,
+	}, {
+		name:   "all levels",
+		option: WithLogHandler(handler),
+		want:   []string{"PRISMA_LOG_QUERIES=y", "RUST_LOG=info"},
+	}, {
+		name:   "info",
+		option: WithLogHandler(handler, LogLevelInfo, LogLevelError),
+		want:   []string{"RUST_LOG=info"},
+	}, {
+		name:   "warn",
+		option: WithLogHandler(handler, LogLevelWarn),
+		want:   []string{"RUST_LOG=warn"},
+	}, {
+		name:   "error",
+		option: WithLogHandler(handler, LogLevelError),
+		want:   nil,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewQueryEngine("", false, "", "", tt.option)
+			assert.Equal(t, tt.want, e.logEnv())
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// MetricsFormat describes the format in which the query engine returns metrics
+type MetricsFormat string
+
+const (
+	// MetricsFormatJSON returns metrics as counters, gauges and histograms in JSON
+	MetricsFormatJSON MetricsFormat = "json"
+	// MetricsFormatPrometheus returns metrics in the Prometheus text format
+	MetricsFormatPrometheus MetricsFormat = "prometheus"
+)
+
+// WithMetrics starts the query engine with metrics enabled, so they can be read with Metrics. It has no effect on
+// query engines connected with WithEngineURL, which need to be started with metrics enabled.
+func WithMetrics(enabled bool) QueryEngineOption {
+	return func(e *QueryEngine) {
+		e.metrics = enabled
+	}
+}
+
+// Metrics returns the query engine metrics, such as connection pool usage, query counts and query durations
+func (e *QueryEngine) Metrics(ctx context.Context, format MetricsFormat) ([]byte, error) {
+	// the body contains global labels which are added to all metrics
+	body, err := e.supervisedRequest(ctx, "GET", "/metrics?format="+string(format), map[string]string{}, true)
+	if err != nil {
+		if errors.Is(err, errNotFound) {
+			return nil, fmt.Errorf("metrics are not enabled; create the client with WithMetrics and add the \"metrics\" preview feature to the generator block of your schema")
+		}
+		return nil, fmt.Errorf("metrics request failed: %w", err)
+	}
+	return body, nil
+}
@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryEngine_WithMetrics(t *testing.T) {
+	useFakeEngine(t)
+	t.Setenv("DATABASE_URL", "postgresql://localhost")
+
+	datasources := `[{"name":"db","provider":"postgresql","activeProvider":"postgresql","url":{"fromEnvVar":"DATABASE_URL","value":""}}]`
+	tests := []struct {
+		name    string
+		enabled bool
+		err     string
+	}{{
+		name:    "enabled",
+		enabled: true,
+	}, {
+		name: "disabled by default",
+		err:  "metrics are not enabled",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewQueryEngine("", false, datasources, "", WithTransport(TransportTCP), WithMetrics(tt.enabled))
+			if err := e.Connect(); err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				assert.NoError(t, e.Disconnect())
+			}()
+
+			_, err := e.Metrics(context.Background(), MetricsFormatJSON)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -3,33 +3,33 @@ package mock
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"strings"
 
+	"github.com/steebchen/prisma-client-go/engine"
 	"github.com/steebchen/prisma-client-go/engine/protocol"
 )
 
+// ErrUnexpectedQuery is returned for queries which don't match any expectation. Unexpected queries are reported
+// when calling ensure.
+var ErrUnexpectedQuery = errors.New("unexpected query")
+
 func (e *Engine) Do(_ context.Context, payload interface{}, v interface{}) error {
 	e.expMu.Lock()
 	defer e.expMu.Unlock()
 
 	expectations := *e.expectations
 
-	n := -1
-	for i, e := range expectations {
-		req := payload.(protocol.GQLRequest)
-		str, err := e.Query.Build()
-		if err != nil {
-			return err
-		}
-		if str == req.Query {
-			n = i
-			break
-		}
-	}
-	if n == -1 {
-		panic("could not find query")
+	req := payload.(protocol.GQLRequest)
+	n, err := find(expectations, req.Query)
+	if err != nil {
+		return e.fail(err)
 	}
-	var retErr error
+	expectations[n].Calls++
+	expectations[n].Success = true
+
 	switch {
 	case expectations[n].Want != nil:
 		r, err := json.Marshal(expectations[n].Want)
@@ -40,16 +40,276 @@ func (e *Engine) Do(_ context.Context, payload interface{}, v interface{}) error
 			return fmt.Errorf("error happened at marshaling expectation want: %w", err)
 		}
 	case expectations[n].WantErr != nil:
-		retErr = expectations[n].WantErr
+		return expectations[n].WantErr
 	default:
-		panic("need to define either Want or WantErr")
+		return e.fail(fmt.Errorf("need to define either Want or WantErr for query `%s`", req.Query))
 	}
-	expectations[n].Success = true
-	*e.expectations = expectations
-	return retErr
+	return nil
+}
+
+// Batch matches each query of the batch against the expectations in order. A transaction fails as a whole with the
+// first error of its expectations, while the queries of a batch without a transaction fail on their own.
+func (e *Engine) Batch(_ context.Context, payload interface{}, v interface{}) error {
+	e.expMu.Lock()
+	defer e.expMu.Unlock()
+
+	expectations := *e.expectations
+
+	req := payload.(protocol.GQLBatchRequest)
+	queries := make([]string, len(req.Batch))
+	for i, r := range req.Batch {
+		queries[i] = r.Query
+	}
+
+	var matches []int
+	if req.Transaction != nil {
+		var err error
+		matches, err = findGroup(expectations, queries)
+		if err != nil {
+			return e.fail(err)
+		}
+		for _, n := range matches {
+			expectations[n].Calls++
+		}
+	}
+	if matches == nil {
+		for _, query := range queries {
+			n, err := find(expectations, query)
+			if err != nil {
+				return e.fail(err)
+			}
+			expectations[n].Calls++
+			matches = append(matches, n)
+		}
+	}
+
+	response := protocol.GQLBatchResponse{
+		Result: make([]protocol.GQLResponse, len(matches)),
+	}
+	var txErr error
+	for i, n := range matches {
+		expectations[n].Success = true
+		switch {
+		case expectations[n].Want != nil:
+			r, err := json.Marshal(expectations[n].Want)
+			if err != nil {
+				return fmt.Errorf("error happened at marshaling expectation want: %w", err)
+			}
+			response.Result[i].Data.Result = r
+		case expectations[n].WantErr != nil:
+			if txErr == nil {
+				txErr = expectations[n].WantErr
+			}
+			response.Result[i].Errors = []protocol.GQLError{{
+				Message: expectations[n].WantErr.Error(),
+				Err:     expectations[n].WantErr,
+			}}
+		default:
+			return e.fail(fmt.Errorf("need to define either Want or WantErr for query `%s`", queries[i]))
+		}
+	}
+
+	if req.Transaction != nil && txErr != nil {
+		return txErr
+	}
+
+	*v.(*protocol.GQLBatchResponse) = response
+	return nil
+}
+
+// fail records an error, so it's reported when calling ensure, and returns it
+func (e *Engine) fail(err error) error {
+	e.unexpected = append(e.unexpected, err)
+	return err
+}
+
+// Unexpected returns the errors of unexpected queries
+func (e *Engine) Unexpected() []error {
+	e.expMu.Lock()
+	defer e.expMu.Unlock()
+
+	return append([]error(nil), e.unexpected...)
+}
+
+// find returns the index of the expectation of a query which is not part of a transaction. Expectations which
+// were not met yet are preferred.
+func find(expectations []Expectation, query string) (int, error) {
+	actual, err := parseQuery(query)
+	if err != nil {
+		return -1, fmt.Errorf("could not parse query `%s`: %w", query, err)
+	}
+
+	var matches []int
+	for i, e := range expectations {
+		if e.Group != 0 {
+			continue
+		}
+		diffs, err := e.compare(actual)
+		if err != nil {
+			return -1, err
+		}
+		if len(diffs) == 0 {
+			matches = append(matches, i)
+		}
+	}
+
+	if len(matches) == 0 {
+		return -1, unexpected(expectations, query, actual)
+	}
+
+	for _, i := range matches {
+		if !expectations[i].exhausted() && ready(expectations, i) && expectations[i].Calls < expectations[i].minCalls() {
+			return i, nil
+		}
+	}
+	for _, i := range matches {
+		if !expectations[i].exhausted() && ready(expectations, i) {
+			return i, nil
+		}
+	}
+
+	for _, i := range matches {
+		if expectations[i].exhausted() {
+			continue
+		}
+		for _, j := range expectations[i].After {
+			if expectations[j].Calls < expectations[j].minCalls() {
+				before, _ := expectations[j].Query.Build()
+				return -1, fmt.Errorf("%w: query `%s` was called before query `%s`", ErrUnexpectedQuery, query, before)
+			}
+		}
+	}
+	return -1, fmt.Errorf("%w: query `%s` was called more than %d times", ErrUnexpectedQuery, query, expectations[matches[0]].Times)
+}
+
+// findGroup returns the indexes of the expectations of the transaction which consists of exactly the given queries,
+// preferring transactions which were not met yet, or nil if there is none
+func findGroup(expectations []Expectation, queries []string) ([]int, error) {
+	actual := make([]field, len(queries))
+	for i, query := range queries {
+		var err error
+		actual[i], err = parseQuery(query)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse query `%s`: %w", query, err)
+		}
+	}
+
+	groups := make(map[int][]int)
+	var order []int
+	for i, e := range expectations {
+		if e.Group == 0 {
+			continue
+		}
+		if _, ok := groups[e.Group]; !ok {
+			order = append(order, e.Group)
+		}
+		groups[e.Group] = append(groups[e.Group], i)
+	}
+
+	var match []int
+	for _, group := range order {
+		indexes := groups[group]
+		if len(indexes) != len(queries) {
+			continue
+		}
+		equal := true
+		met := true
+		for i, n := range indexes {
+			diffs, err := expectations[n].compare(actual[i])
+			if err != nil {
+				return nil, err
+			}
+			if len(diffs) > 0 || expectations[n].exhausted() || !ready(expectations, n) {
+				equal = false
+				break
+			}
+			met = met && expectations[n].Calls >= expectations[n].minCalls()
+		}
+		if !equal {
+			continue
+		}
+		if !met {
+			return indexes, nil
+		}
+		if match == nil {
+			match = indexes
+		}
+	}
+	return match, nil
+}
+
+// compare returns the differences between the expected query and the actual query
+func (e Expectation) compare(actual field) ([]string, error) {
+	str, err := e.Query.Build()
+	if err != nil {
+		return nil, err
+	}
+	expected, err := parseQuery(str)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse expected query `%s`: %w", str, err)
+	}
+	return compare(expected, actual, e.Matcher), nil
+}
+
+// ready returns whether the expectations which need to be met before the expectation at index i were met
+func ready(expectations []Expectation, i int) bool {
+	for _, j := range expectations[i].After {
+		if expectations[j].Calls < expectations[j].minCalls() {
+			return false
+		}
+	}
+	return true
+}
+
+// unexpected returns an error which describes the differences to the closest expectation of a query
+func unexpected(expectations []Expectation, query string, actual field) error {
+	var closest string
+	var closestDiffs []string
+	closestScore := 0
+	for _, e := range expectations {
+		diffs, err := e.compare(actual)
+		if err != nil {
+			continue
+		}
+		// prefer expectations of the same method
+		score := len(diffs)
+		if score > 0 && strings.HasPrefix(diffs[0], "method:") {
+			score = math.MaxInt
+		}
+		if closest == "" || score < closestScore {
+			closest, _ = e.Query.Build()
+			closestDiffs = diffs
+			closestScore = score
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("\ngot:\n\t" + query)
+	if closest == "" {
+		b.WriteString("\nno expectations defined")
+	} else {
+		b.WriteString("\nclosest expectation:\n\t" + closest)
+		b.WriteString("\ndifferences:")
+		for _, diff := range closestDiffs {
+			b.WriteString("\n\t- " + diff)
+		}
+	}
+
+	return fmt.Errorf("%w:%s", ErrUnexpectedQuery, b.String())
+}
+
+func (e *Engine) StartTx(context.Context, protocol.TransactionStartRequest) (string, error) {
+	return "mock", nil
+}
+
+func (e *Engine) CommitTx(context.Context, string) error {
+	return nil
+}
+
+func (e *Engine) RollbackTx(context.Context, string) error {
+	return nil
 }
 
-func (e *Engine) Batch(context.Context, interface{}, interface{}) error {
-	// TODO
-	panic("TODO")
+func (e *Engine) Metrics(context.Context, engine.MetricsFormat) ([]byte, error) {
+	return nil, fmt.Errorf("metrics are not available in a mock client")
 }
@@ -1,7 +1,13 @@
 package mock
 
 import (
+	"context"
 	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/steebchen/prisma-client-go/engine"
 )
 
 func New(expectations *[]Expectation) *Engine {
@@ -13,12 +19,23 @@ func New(expectations *[]Expectation) *Engine {
 type Engine struct {
 	expectations *[]Expectation
 	expMu        sync.Mutex
+
+	// unexpected contains the errors of unexpected queries
+	unexpected []error
 }
 
 func (e *Engine) Name() string {
 	return "mock"
 }
 
+func (e *Engine) Protocol() engine.Protocol {
+	return engine.ProtocolGraphQL
+}
+
+func (e *Engine) TracerProvider() trace.TracerProvider {
+	return noop.NewTracerProvider()
+}
+
 func (e *Engine) Connect() error {
 	panic("this is a mock client – you don't need to connect or disconnect this client")
 }
@@ -26,3 +43,12 @@ func (e *Engine) Connect() error {
 func (e *Engine) Disconnect() error {
 	panic("this is a mock client – you don't need to connect or disconnect this client")
 }
+
+// Ping always succeeds, as a mock client doesn't need to be connected
+func (e *Engine) Ping(context.Context) error {
+	return nil
+}
+
+func (e *Engine) Status() engine.Status {
+	return engine.Status{State: engine.StateConnected}
+}
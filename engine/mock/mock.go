@@ -11,6 +11,60 @@ type Expectation struct {
 	Want    interface{}
 	WantErr error
 	Success bool
+	// Group is shared by the expectations which need to be sent together in a transaction; 0 if not grouped
+	Group int
+	// Matcher configures how queries are compared to Query
+	Matcher Matcher
+	// Times is the exact number of expected calls. If 0, the expectation needs to be called at least once.
+	Times int
+	// AnyTimes allows any number of calls, including none
+	AnyTimes bool
+	// After contains the indexes of the expectations which need to be met before this one, see InOrder
+	After []int
+	// Calls counts how often the expectation was called
+	Calls int
+}
+
+// minCalls returns the number of calls which are needed to meet the expectation
+func (e Expectation) minCalls() int {
+	switch {
+	case e.AnyTimes:
+		return 0
+	case e.Times > 0:
+		return e.Times
+	}
+	return 1
+}
+
+// exhausted returns whether the expectation may not be called again
+func (e Expectation) exhausted() bool {
+	return !e.AnyTimes && e.Times > 0 && e.Calls >= e.Times
+}
+
+// ExpectedQuery refers to an expectation which was set with Returns, ReturnsMany or Errors
+type ExpectedQuery struct {
+	expectations *[]Expectation
+	index        int
+}
+
+// NewExpectedQuery refers to the expectation at the given index
+func NewExpectedQuery(expectations *[]Expectation, index int) ExpectedQuery {
+	return ExpectedQuery{
+		expectations: expectations,
+		index:        index,
+	}
+}
+
+// Times expects the query to be called exactly n times
+func (q ExpectedQuery) Times(n int) ExpectedQuery {
+	(*q.expectations)[q.index].Times = n
+	return q
+}
+
+// AnyTimes allows the query to be called any number of times, including never
+func (q ExpectedQuery) AnyTimes() ExpectedQuery {
+	(*q.expectations)[q.index].AnyTimes = true
+	return q
 }
 
 type Query interface {
@@ -19,19 +73,61 @@ type Query interface {
 
 type Mock struct {
 	Expectations *[]Expectation
+
+	// Engine is the engine of the mock client, which records unexpected queries
+	Engine *Engine
+
+	groups int
+}
+
+// ExpectTransaction expects the given queries to be sent together in a transaction, in the given order, e.g. with
+// client.Prisma.Transaction. If one of them returns an error, the whole transaction fails with that error.
+func (m *Mock) ExpectTransaction(queries ...ExpectedQuery) {
+	m.groups++
+	for _, q := range queries {
+		(*m.Expectations)[q.index].Group = m.groups
+	}
+}
+
+// InOrder expects the given queries to be called in the given order. Queries which are not passed to InOrder can
+// be called at any time.
+func (m *Mock) InOrder(queries ...ExpectedQuery) {
+	for i := 1; i < len(queries); i++ {
+		e := &(*m.Expectations)[queries[i].index]
+		e.After = append(e.After, queries[i-1].index)
+	}
 }
 
+// Ensure reports unexpected queries and expectations which were not met
 func (m *Mock) Ensure(t *testing.T) {
+	t.Helper()
+
 	if len(*m.Expectations) == 0 {
 		t.Fatalf("no expectations defined")
 	}
+
+	if m.Engine != nil {
+		for _, err := range m.Engine.Unexpected() {
+			t.Error(err)
+		}
+	}
+
 	for _, e := range *m.Expectations {
-		if !e.Success {
-			str, err := e.Query.Build()
-			if err != nil {
-				t.Fatalf("could not build query: %s", err)
-			}
-			t.Fatalf("expectation not met for query `%s` and result `%s`, error `%s`", str, e.Want, e.WantErr)
+		if e.Calls >= e.minCalls() {
+			continue
+		}
+		str, err := e.Query.Build()
+		if err != nil {
+			t.Errorf("could not build query: %s", err)
+			continue
+		}
+		switch {
+		case e.Times > 0:
+			t.Errorf("expectation not met for query `%s`: expected %d calls, got %d", str, e.Times, e.Calls)
+		case e.Group != 0:
+			t.Errorf("expectation not met for query `%s` in a transaction and result `%s`, error `%s`", str, e.Want, e.WantErr)
+		default:
+			t.Errorf("expectation not met for query `%s` and result `%s`, error `%s`", str, e.Want, e.WantErr)
 		}
 	}
 }
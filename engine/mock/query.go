@@ -0,0 +1,411 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Matcher configures how a query is compared to the query of an expectation. By default, queries need to be equal,
+// apart from the order of object fields.
+type Matcher struct {
+	// AnyValue contains the names of fields whose values are not compared, wherever they appear in the arguments
+	AnyValue []string
+	// Predicates contains functions which need to return true for the values of the given fields, wherever they
+	// appear in the arguments. Filters and updates such as {equals: "a"} or {set: "a"} are passed as "a"; numbers
+	// are passed as json.Number.
+	Predicates map[string]func(v interface{}) bool
+	// IgnoreSelection ignores the selected fields, e.g. when using Select, Omit or With
+	IgnoreSelection bool
+	// IgnoreOrder compares lists regardless of the order of their items, e.g. of OR filters
+	IgnoreOrder bool
+}
+
+// field is a parsed field of a query, such as result: findUniqueUser(where:{id:"a",}) {id name }
+type field struct {
+	name      string
+	args      map[string]interface{}
+	selection []field
+}
+
+// parseQuery parses a query built by builder.Query.Build
+func parseQuery(query string) (field, error) {
+	p := &parser{input: query}
+
+	// skip the operation and its name, e.g. "query" or "mutation"
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return field{}, err
+		}
+		if tok == "{" {
+			break
+		}
+	}
+
+	if err := p.expect("result"); err != nil {
+		return field{}, err
+	}
+	if err := p.expect(":"); err != nil {
+		return field{}, err
+	}
+
+	result, err := p.field()
+	if err != nil {
+		return field{}, err
+	}
+
+	if err := p.expect("}"); err != nil {
+		return field{}, err
+	}
+
+	return result, nil
+}
+
+type parser struct {
+	input string
+	pos   int
+}
+
+// peek returns the next token without consuming it
+func (p *parser) peek() (string, error) {
+	pos := p.pos
+	tok, err := p.next()
+	p.pos = pos
+	return tok, err
+}
+
+// next returns the next token, which is a punctuation character, a JSON string or a literal such as a name,
+// a number or an enum value
+func (p *parser) next() (string, error) {
+	for p.pos < len(p.input) && isSpace(p.input[p.pos]) {
+		p.pos++
+	}
+	if p.pos >= len(p.input) {
+		return "", fmt.Errorf("unexpected end of query")
+	}
+
+	start := p.pos
+	switch c := p.input[p.pos]; {
+	case strings.IndexByte("{}()[]:,", c) >= 0:
+		p.pos++
+	case c == '"':
+		p.pos++
+		for p.pos < len(p.input) && p.input[p.pos] != '"' {
+			if p.input[p.pos] == '\\' {
+				p.pos++
+			}
+			p.pos++
+		}
+		if p.pos >= len(p.input) {
+			return "", fmt.Errorf("unterminated string at %d", start)
+		}
+		p.pos++
+	default:
+		for p.pos < len(p.input) && !isSpace(p.input[p.pos]) && strings.IndexByte("{}()[]:,\"", p.input[p.pos]) < 0 {
+			p.pos++
+		}
+	}
+
+	return p.input[start:p.pos], nil
+}
+
+func (p *parser) expect(want string) error {
+	tok, err := p.next()
+	if err != nil {
+		return err
+	}
+	if tok != want {
+		return fmt.Errorf("expected %q but got %q at %d", want, tok, p.pos)
+	}
+	return nil
+}
+
+// field parses a field with its optional arguments and selection
+func (p *parser) field() (field, error) {
+	name, err := p.next()
+	if err != nil {
+		return field{}, err
+	}
+	f := field{name: name}
+
+	tok, err := p.peek()
+	if err != nil {
+		return field{}, err
+	}
+
+	if tok == "(" {
+		_, _ = p.next()
+		f.args, err = p.object(")")
+		if err != nil {
+			return field{}, err
+		}
+		if tok, err = p.peek(); err != nil {
+			return field{}, err
+		}
+	}
+
+	if tok == "{" {
+		_, _ = p.next()
+		for {
+			tok, err := p.peek()
+			if err != nil {
+				return field{}, err
+			}
+			if tok == "}" {
+				_, _ = p.next()
+				break
+			}
+			child, err := p.field()
+			if err != nil {
+				return field{}, err
+			}
+			f.selection = append(f.selection, child)
+		}
+	}
+
+	return f, nil
+}
+
+// object parses the fields of an object or of arguments until the closing token
+func (p *parser) object(end string) (map[string]interface{}, error) {
+	o := make(map[string]interface{})
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if tok == end {
+			return o, nil
+		}
+		if tok == "," {
+			continue
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		o[tok] = v
+	}
+}
+
+func (p *parser) value() (interface{}, error) {
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case "{":
+		return p.object("}")
+	case "[":
+		var list []interface{}
+		for {
+			tok, err := p.peek()
+			if err != nil {
+				return nil, err
+			}
+			if tok == "]" {
+				_, _ = p.next()
+				return list, nil
+			}
+			if tok == "," {
+				_, _ = p.next()
+				continue
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(tok))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		// enum values are not quoted
+		return tok, nil
+	}
+	return v, nil
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\t' || c == '\r'
+}
+
+// compare returns the differences between the query of an expectation and an actual query
+func compare(expected, actual field, m Matcher) []string {
+	if expected.name != actual.name {
+		return []string{fmt.Sprintf("method: expected %s, got %s", expected.name, actual.name)}
+	}
+
+	var diffs []string
+	compareValue("", expected.args, actual.args, m, &diffs)
+
+	if !m.IgnoreSelection {
+		if e, a := formatSelection(expected.selection), formatSelection(actual.selection); e != a {
+			diffs = append(diffs, fmt.Sprintf("selection: expected %s, got %s", e, a))
+		}
+	}
+
+	return diffs
+}
+
+func compareValue(path string, expected, actual interface{}, m Matcher, diffs *[]string) {
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for _, key := range keys(e, a) {
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			if contains(m.AnyValue, key) {
+				continue
+			}
+			av, inActual := a[key]
+			if predicate, ok := m.Predicates[key]; ok {
+				if !inActual {
+					*diffs = append(*diffs, fmt.Sprintf("%s: expected a value, got nothing", keyPath))
+				} else if !predicate(unwrap(av)) {
+					*diffs = append(*diffs, fmt.Sprintf("%s: %s doesn't match", keyPath, format(av)))
+				}
+				continue
+			}
+			ev, inExpected := e[key]
+			switch {
+			case !inActual:
+				*diffs = append(*diffs, fmt.Sprintf("%s: expected %s, got nothing", keyPath, format(ev)))
+			case !inExpected:
+				*diffs = append(*diffs, fmt.Sprintf("%s: expected nothing, got %s", keyPath, format(av)))
+			default:
+				compareValue(keyPath, ev, av, m, diffs)
+			}
+		}
+		return
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok {
+			break
+		}
+		if m.IgnoreOrder {
+			if !sameItems(e, a, m) {
+				*diffs = append(*diffs, fmt.Sprintf("%s: expected %s in any order, got %s", path, format(e), format(a)))
+			}
+			return
+		}
+		if len(e) != len(a) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: expected %s, got %s", path, format(e), format(a)))
+			return
+		}
+		for i := range e {
+			compareValue(fmt.Sprintf("%s[%d]", path, i), e[i], a[i], m, diffs)
+		}
+		return
+	default:
+		if reflect.DeepEqual(expected, actual) {
+			return
+		}
+	}
+
+	*diffs = append(*diffs, fmt.Sprintf("%s: expected %s, got %s", path, format(expected), format(actual)))
+}
+
+// sameItems returns whether both lists contain the same items, regardless of their order
+func sameItems(expected, actual []interface{}, m Matcher) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	used := make([]bool, len(actual))
+	for _, e := range expected {
+		found := false
+		for i, a := range actual {
+			if used[i] {
+				continue
+			}
+			var diffs []string
+			compareValue("", e, a, m, &diffs)
+			if len(diffs) == 0 {
+				used[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// unwrap returns the value of filters and updates such as {equals: "a"} or {set: "a"}
+func unwrap(v interface{}) interface{} {
+	if o, ok := v.(map[string]interface{}); ok && len(o) == 1 {
+		if inner, ok := o["equals"]; ok {
+			return inner
+		}
+		if inner, ok := o["set"]; ok {
+			return inner
+		}
+	}
+	return v
+}
+
+func keys(a, b map[string]interface{}) []string {
+	var list []string
+	for k := range a {
+		list = append(list, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			list = append(list, k)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func format(v interface{}) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+func formatSelection(selection []field) string {
+	var parts []string
+	for _, f := range selection {
+		part := f.name
+		if len(f.args) > 0 {
+			part += format(f.args)
+		}
+		if len(f.selection) > 0 {
+			part += " " + formatSelection(f.selection)
+		}
+		parts = append(parts, part)
+	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
@@ -0,0 +1,125 @@
+package mock
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuery(t *testing.T) {
+	f, err := parseQuery(`query {result: findManyUser(where:{name:{equals:"a \"b\"",},OR:[{age:{gt:5,}},],},orderBy:[{name:ASC,},],take:2) {id name posts (take:3){id }}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, field{
+		name: "findManyUser",
+		args: map[string]interface{}{
+			"where": map[string]interface{}{
+				"name": map[string]interface{}{"equals": `a "b"`},
+				"OR": []interface{}{
+					map[string]interface{}{"age": map[string]interface{}{"gt": json.Number("5")}},
+				},
+			},
+			"orderBy": []interface{}{map[string]interface{}{"name": "ASC"}},
+			"take":    json.Number("2"),
+		},
+		selection: []field{
+			{name: "id"},
+			{name: "name"},
+			{name: "posts", args: map[string]interface{}{"take": json.Number("3")}, selection: []field{{name: "id"}}},
+		},
+	}, f)
+
+	raw, err := parseQuery(`mutation {result: executeRaw(query:"SELECT 1",parameters:"[]") }`)
+	assert.NoError(t, err)
+	assert.Equal(t, field{
+		name: "executeRaw",
+		args: map[string]interface{}{"query": "SELECT 1", "parameters": "[]"},
+	}, raw)
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		matcher  Matcher
+		diffs    []string
+	}{{
+		name:     "equal",
+		expected: `query {result: findUniqueUser(where:{id:"a",}) {id name }}`,
+		actual:   `query {result: findUniqueUser(where:{id:"a",}) {id name }}`,
+	}, {
+		name:     "object fields in any order",
+		expected: `mutation {result: createOneUser(data:{id:"a",name:"b",}) {id name }}`,
+		actual:   `mutation {result: createOneUser(data:{name:"b",id:"a",}) {id name }}`,
+	}, {
+		name:     "different method",
+		expected: `query {result: findUniqueUser(where:{id:"a",}) {id name }}`,
+		actual:   `query {result: findFirstUser(where:{id:"a",}) {id name }}`,
+		diffs:    []string{"method: expected findUniqueUser, got findFirstUser"},
+	}, {
+		name:     "different value",
+		expected: `query {result: findUniqueUser(where:{id:"a",}) {id name }}`,
+		actual:   `query {result: findUniqueUser(where:{id:"b",}) {id name }}`,
+		diffs:    []string{`where.id: expected "a", got "b"`},
+	}, {
+		name:     "missing and additional arguments",
+		expected: `query {result: findManyUser(where:{name:{equals:"a",},},take:1) {id name }}`,
+		actual:   `query {result: findManyUser(where:{name:{equals:"a",},},skip:1) {id name }}`,
+		diffs:    []string{"skip: expected nothing, got 1", "take: expected 1, got nothing"},
+	}, {
+		name:     "any value",
+		expected: `query {result: findManyUser(where:{name:{equals:"a",},}) {id name }}`,
+		actual:   `query {result: findManyUser(where:{name:{contains:"b",},}) {id name }}`,
+		matcher:  Matcher{AnyValue: []string{"name"}},
+	}, {
+		name:     "predicate",
+		expected: `mutation {result: updateOneUser(where:{id:"a",},data:{name:{set:"",},}) {id name }}`,
+		actual:   `mutation {result: updateOneUser(where:{id:"a",},data:{name:{set:"long name",},}) {id name }}`,
+		matcher: Matcher{Predicates: map[string]func(v interface{}) bool{
+			"name": func(v interface{}) bool {
+				return len(v.(string)) > 5
+			},
+		}},
+	}, {
+		name:     "failed predicate",
+		expected: `mutation {result: updateOneUser(where:{id:"a",},data:{name:{set:"",},}) {id name }}`,
+		actual:   `mutation {result: updateOneUser(where:{id:"a",},data:{name:{set:"short",},}) {id name }}`,
+		matcher: Matcher{Predicates: map[string]func(v interface{}) bool{
+			"name": func(v interface{}) bool {
+				return len(v.(string)) > 5
+			},
+		}},
+		diffs: []string{`data.name: {"set":"short"} doesn't match`},
+	}, {
+		name:     "different selection",
+		expected: `query {result: findUniqueUser(where:{id:"a",}) {id name }}`,
+		actual:   `query {result: findUniqueUser(where:{id:"a",}) {id }}`,
+		diffs:    []string{"selection: expected {id name}, got {id}"},
+	}, {
+		name:     "ignore selection",
+		expected: `query {result: findUniqueUser(where:{id:"a",}) {id name }}`,
+		actual:   `query {result: findUniqueUser(where:{id:"a",}) {id }}`,
+		matcher:  Matcher{IgnoreSelection: true},
+	}, {
+		name:     "list order",
+		expected: `query {result: findManyUser(where:{OR:[{id:"a",},{id:"b",},],}) {id }}`,
+		actual:   `query {result: findManyUser(where:{OR:[{id:"b",},{id:"a",},],}) {id }}`,
+		diffs:    []string{`where.OR[0].id: expected "a", got "b"`, `where.OR[1].id: expected "b", got "a"`},
+	}, {
+		name:     "ignore list order",
+		expected: `query {result: findManyUser(where:{OR:[{id:"a",},{id:"b",},],}) {id }}`,
+		actual:   `query {result: findManyUser(where:{OR:[{id:"b",},{id:"a",},],}) {id }}`,
+		matcher:  Matcher{IgnoreOrder: true},
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			expected, err := parseQuery(tt.expected)
+			assert.NoError(t, err)
+			actual, err := parseQuery(tt.actual)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.diffs, compare(expected, actual, tt.matcher))
+		})
+	}
+}
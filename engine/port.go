@@ -1,10 +1,49 @@
 package engine
 
 import (
+	"context"
+	"fmt"
 	"net"
+	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
+
+	"github.com/steebchen/prisma-client-go/binaries/platform"
+	"github.com/steebchen/prisma-client-go/logger"
+)
+
+// Transport describes how the client connects to the query engine
+type Transport string
+
+const (
+	// TransportTCP runs the query engine on a free localhost TCP port
+	TransportTCP Transport = "tcp"
+	// TransportUnix runs the query engine on a unix domain socket, which avoids port races and
+	// is not reachable by other processes via the network
+	TransportUnix Transport = "unix"
 )
 
+// maxSocketPathLength is the maximum length of a unix socket path on common platforms
+const maxSocketPathLength = 100
+
+// WithTransport sets how the client connects to the query engine.
+// The default is TransportUnix on Linux and TransportTCP on other platforms.
+func WithTransport(transport Transport) QueryEngineOption {
+	return func(e *QueryEngine) {
+		if transport != "" {
+			e.transport = transport
+		}
+	}
+}
+
+func defaultTransport() Transport {
+	if platform.Name() == "linux" {
+		return TransportUnix
+	}
+	return TransportTCP
+}
+
 func getPort() (string, error) {
 	addr, err := net.ResolveTCPAddr("tcp", "localhost:0")
 	if err != nil {
@@ -22,3 +61,43 @@ func getPort() (string, error) {
 	port := l.Addr().(*net.TCPAddr).Port
 	return strconv.Itoa(port), nil
 }
+
+// getSocketPath returns a new unix socket path in a private directory in the temp directory, so other users can't
+// connect to the query engine or place a socket at the path beforehand. The directory is removed with removeSocket.
+func getSocketPath() (string, error) {
+	// MkdirTemp creates the directory with mode 0700
+	dir, err := os.MkdirTemp("", "prisma-query-engine-")
+	if err != nil {
+		return "", fmt.Errorf("create socket directory: %w", err)
+	}
+
+	socket := filepath.Join(dir, "query-engine.sock")
+	if len(socket) > maxSocketPathLength {
+		removeSocket(socket)
+		return "", fmt.Errorf("socket path %s exceeds %d characters", socket, maxSocketPathLength)
+	}
+
+	return socket, nil
+}
+
+// removeSocket removes a unix socket and its directory, if any
+func removeSocket(socket string) {
+	if socket == "" {
+		return
+	}
+	if err := os.RemoveAll(filepath.Dir(socket)); err != nil {
+		logger.Debug.Printf("could not remove query engine socket %s: %s", socket, err)
+	}
+}
+
+// newUnixClient returns a http client which connects to the given unix socket
+func newUnixClient(socket string) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+}
@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_getSocketPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires unix permissions")
+	}
+
+	socket, err := getSocketPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := getSocketPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeSocket(other)
+	assert.NotEqual(t, filepath.Dir(socket), filepath.Dir(other))
+
+	// only the current user can access the directory of the socket
+	info, err := os.Stat(filepath.Dir(socket))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, info.IsDir())
+	assert.Equal(t, os.FileMode(0o700), info.Mode().Perm())
+
+	removeSocket(socket)
+	_, err = os.Stat(filepath.Dir(socket))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_newUnixClient(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires unix sockets")
+	}
+
+	socket, err := getSocketPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeSocket(socket)
+
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	})}
+	go func() {
+		_ = s.Serve(l)
+	}()
+	defer s.Close()
+
+	// the host is ignored, as the client always dials the socket
+	res, err := newUnixClient(socket).Get("http://localhost/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"status":"ok"}`, string(body))
+}
+
+func TestQueryEngine_spawnFallback(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a shell")
+	}
+
+	// a query engine which records its transport argument and fails to start
+	dir := t.TempDir()
+	file := filepath.Join(dir, "query-engine")
+	starts := filepath.Join(dir, "starts")
+	script := `#!/bin/sh
+echo "$1" >> ` + starts + `
+echo '{"is_panic":false,"message":"could not start"}' >&2
+exit 1
+`
+	if err := os.WriteFile(file, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewQueryEngine("", false, "[]", "", WithTransport(TransportUnix))
+	assert.Error(t, e.spawn(file))
+	// the error of the previous attempt is reset before restarting
+	e.lastEngineError = ""
+	assert.Error(t, e.spawn(file))
+
+	// each attempt tries the unix socket first and only falls back to tcp for that attempt
+	content, err := os.ReadFile(starts)
+	assert.NoError(t, err)
+	assert.Equal(t, "--unix-path\n-p\n--unix-path\n-p\n", string(content))
+	assert.Equal(t, TransportUnix, e.transport)
+}
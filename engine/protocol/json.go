@@ -0,0 +1,200 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// JSONRequest is the payload for JSON protocol queries
+type JSONRequest struct {
+	ModelName string    `json:"modelName,omitempty"`
+	Action    string    `json:"action"`
+	Query     JSONQuery `json:"query"`
+}
+
+// JSONQuery contains the arguments and the selection of a JSON protocol query or a nested relation
+type JSONQuery struct {
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Selection map[string]interface{} `json:"selection"`
+}
+
+// JSONBatchRequest is the payload for JSON protocol batch queries
+type JSONBatchRequest struct {
+	Batch []JSONRequest `json:"batch"`
+	// Transaction (optional) runs the batch in a transaction
+	Transaction *JSONTransaction `json:"transaction,omitempty"`
+}
+
+// JSONTransaction contains the transaction options of a JSON protocol batch
+type JSONTransaction struct {
+	IsolationLevel string `json:"isolationLevel,omitempty"`
+}
+
+// TypedValue is a JSON protocol value which is tagged with its type, e.g. {"$type":"DateTime","value":"..."}
+type TypedValue struct {
+	Type  string      `json:"$type"`
+	Value interface{} `json:"value"`
+}
+
+// typedValueTypes contains the tagged types which are returned by the query engine
+var typedValueTypes = map[string]bool{
+	"DateTime": true,
+	"Decimal":  true,
+	"BigInt":   true,
+	"Bytes":    true,
+	"Json":     true,
+}
+
+type jsonResponse struct {
+	Data        map[string]json.RawMessage `json:"data"`
+	Errors      json.RawMessage            `json:"errors"`
+	Extensions  json.RawMessage            `json:"extensions"`
+	BatchResult []jsonResponse             `json:"batchResult"`
+}
+
+type decodedResponse struct {
+	Data        *Data             `json:"data,omitempty"`
+	Errors      json.RawMessage   `json:"errors,omitempty"`
+	Extensions  json.RawMessage   `json:"extensions,omitempty"`
+	BatchResult []decodedResponse `json:"batchResult,omitempty"`
+}
+
+// DecodeJSONResponse converts a JSON protocol response to the shape of a GraphQL protocol response, so both protocols
+// can be handled the same way. The data of a query is moved to the result field, and tagged values are replaced with
+// the representation the GraphQL protocol uses, e.g. {"$type":"BigInt","value":"1"} becomes "1".
+func DecodeJSONResponse(body []byte) ([]byte, error) {
+	var response jsonResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("json protocol response unmarshal: %w", err)
+	}
+
+	decoded, err := response.decode()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(decoded)
+}
+
+func (r jsonResponse) decode() (decodedResponse, error) {
+	decoded := decodedResponse{
+		Errors:     r.Errors,
+		Extensions: r.Extensions,
+	}
+
+	// the data of a query is keyed by the action and the model name, e.g. findUniqueUser
+	for _, data := range r.Data {
+		result, err := DecodeTypedValues(data)
+		if err != nil {
+			return decodedResponse{}, err
+		}
+		decoded.Data = &Data{Result: result}
+	}
+
+	for _, inner := range r.BatchResult {
+		d, err := inner.decode()
+		if err != nil {
+			return decodedResponse{}, err
+		}
+		decoded.BatchResult = append(decoded.BatchResult, d)
+	}
+
+	return decoded, nil
+}
+
+// DecodeTypedValues replaces all tagged JSON protocol values in data with their plain value while keeping the order
+// of object keys intact.
+func DecodeTypedValues(data json.RawMessage) (json.RawMessage, error) {
+	data = bytes.TrimSpace(data)
+	if !bytes.Contains(data, []byte(`"$type"`)) {
+		return data, nil
+	}
+
+	switch data[0] {
+	case '{':
+		return decodeObject(data)
+	case '[':
+		return decodeArray(data)
+	}
+
+	return data, nil
+}
+
+func decodeObject(data json.RawMessage) (json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	var values []json.RawMessage
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		value, err = DecodeTypedValues(value)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key.(string))
+		values = append(values, value)
+	}
+
+	if len(keys) == 2 && (keys[0] == "$type" && keys[1] == "value" || keys[0] == "value" && keys[1] == "$type") {
+		typeIndex := 0
+		if keys[1] == "$type" {
+			typeIndex = 1
+		}
+		var t string
+		if err := json.Unmarshal(values[typeIndex], &t); err == nil && typedValueTypes[t] {
+			return values[1-typeIndex], nil
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(values[i])
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+func decodeArray(data json.RawMessage) (json.RawMessage, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, item := range items {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		value, err := DecodeTypedValues(item)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte(']')
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,48 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeJSONResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{{
+		name: "result",
+		body: `{"data":{"findUniqueUser":{"id":"a","name":"b"}}}`,
+		want: `{"data":{"result":{"id":"a","name":"b"}}}`,
+	}, {
+		name: "typed values",
+		body: `{"data":{"findManyUser":[{"id":"a","createdAt":{"$type":"DateTime","value":"2020-01-01T00:00:00.000Z"},"big":{"$type":"BigInt","value":"1"},"dec":{"$type":"Decimal","value":"1.5"},"bytes":{"$type":"Bytes","value":"YWJj"},"json":{"$type":"Json","value":"{\"a\":1}"}}]}}`,
+		want: `{"data":{"result":[{"id":"a","createdAt":"2020-01-01T00:00:00.000Z","big":"1","dec":"1.5","bytes":"YWJj","json":"{\"a\":1}"}]}}`,
+	}, {
+		name: "unknown tagged values are kept",
+		body: `{"data":{"findUniqueUser":{"json":{"$type":"Custom","value":"a"}}}}`,
+		want: `{"data":{"result":{"json":{"$type":"Custom","value":"a"}}}}`,
+	}, {
+		name: "null result",
+		body: `{"data":{"findUniqueUser":null}}`,
+		want: `{"data":{"result":null}}`,
+	}, {
+		name: "errors",
+		body: `{"errors":[{"error":"a","user_facing_error":{"error_code":"P2002"}}]}`,
+		want: `{"errors":[{"error":"a","user_facing_error":{"error_code":"P2002"}}]}`,
+	}, {
+		name: "batch",
+		body: `{"batchResult":[{"data":{"createOneUser":{"id":"a"}}},{"errors":[{"error":"b"}]}]}`,
+		want: `{"batchResult":[{"data":{"result":{"id":"a"}}},{"errors":[{"error":"b"}]}]}`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeJSONResponse([]byte(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
@@ -3,6 +3,7 @@ package protocol
 import (
 	"encoding/json"
 	"strings"
+	"time"
 )
 
 // GQLResponse is the default GraphQL response
@@ -29,8 +30,47 @@ type GQLRequest struct {
 
 // GQLBatchRequest is the payload for GraphQL queries
 type GQLBatchRequest struct {
-	Batch       []GQLRequest `json:"batch"`
-	Transaction bool         `json:"transaction"`
+	Batch []GQLRequest `json:"batch"`
+	// Transaction (optional) runs the batch in a transaction
+	Transaction *GQLTransaction `json:"transaction,omitempty"`
+}
+
+// GQLTransaction contains the transaction options of a GraphQL batch
+type GQLTransaction struct {
+	IsolationLevel string `json:"isolationLevel,omitempty"`
+}
+
+// TransactionStartRequest is the payload to start an interactive transaction
+type TransactionStartRequest struct {
+	// MaxWait is the maximum time in milliseconds to wait for a connection
+	MaxWait int `json:"max_wait"`
+	// Timeout is the maximum time in milliseconds the transaction may run before it's rolled back
+	Timeout int `json:"timeout"`
+	// IsolationLevel (optional) sets the transaction isolation level
+	IsolationLevel string `json:"isolation_level,omitempty"`
+}
+
+// TransactionStartResponse is the response of starting an interactive transaction
+type TransactionStartResponse struct {
+	ID string `json:"id"`
+}
+
+// EngineSpan is a span of the query engine, which is returned in the response extensions when tracing is enabled
+type EngineSpan struct {
+	Name         string                 `json:"name"`
+	TraceID      string                 `json:"trace_id"`
+	SpanID       string                 `json:"span_id"`
+	ParentSpanID string                 `json:"parent_span_id"`
+	StartTime    HrTime                 `json:"start_time"`
+	EndTime      HrTime                 `json:"end_time"`
+	Attributes   map[string]interface{} `json:"attributes"`
+}
+
+// HrTime is a high-resolution timestamp of seconds and nanoseconds
+type HrTime [2]int64
+
+func (t HrTime) Time() time.Time {
+	return time.Unix(t[0], t[1])
 }
 
 type UserFacingError struct {
@@ -44,8 +84,28 @@ func (e *UserFacingError) Error() string {
 	return e.Message
 }
 
+// Meta contains additional information of a user facing error, depending on the error code
 type Meta struct {
-	Target interface{} `json:"target"` // can be of type []string or string
+	Target       interface{} `json:"target"`     // can be of type []string or string
+	Constraint   interface{} `json:"constraint"` // can be of type []string or string
+	FieldName    string      `json:"field_name"`
+	ColumnName   string      `json:"column_name"`
+	ModelName    string      `json:"modelName"`
+	Cause        string      `json:"cause"`
+	DatabaseHost string      `json:"database_host"`
+	DatabasePort interface{} `json:"database_port"` // can be of type string or number
+	Time         string      `json:"time"`
+
+	// Raw contains all meta fields as returned by the query engine
+	Raw map[string]interface{} `json:"-"`
+}
+
+func (m *Meta) UnmarshalJSON(data []byte) error {
+	type meta Meta
+	if err := json.Unmarshal(data, (*meta)(m)); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &m.Raw)
 }
 
 // GQLError is a GraphQL Message
@@ -53,6 +113,9 @@ type GQLError struct {
 	Message         string           `json:"error"`
 	UserFacingError *UserFacingError `json:"user_facing_error"`
 	Path            []string         `json:"path"`
+
+	// Err is the typed error of engines which don't send errors over the wire, such as the mock engine
+	Err error `json:"-"`
 }
 
 func (e *GQLError) Error() string {
@@ -12,10 +12,12 @@ import (
 	"path"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
 	"github.com/steebchen/prisma-client-go/binaries"
 	"github.com/steebchen/prisma-client-go/engine/protocol"
 	"github.com/steebchen/prisma-client-go/logger"
-	"github.com/steebchen/prisma-client-go/runtime/types"
 )
 
 func NewDataProxyEngine(schema, connectionURL string) *DataProxyEngine {
@@ -95,12 +97,8 @@ func (e *DataProxyEngine) Disconnect() error {
 
 func (e *DataProxyEngine) Do(ctx context.Context, payload interface{}, into interface{}) error {
 	startReq := time.Now()
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("payload marshal: %w", err)
-	}
 
-	body, err := e.retryableRequest(ctx, "POST", "/graphql", data)
+	body, err := e.rawRequest(ctx, payload)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
@@ -109,22 +107,8 @@ func (e *DataProxyEngine) Do(ctx context.Context, payload interface{}, into inte
 
 	startParse := time.Now()
 
-	var response protocol.GQLResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return fmt.Errorf("json gql resopnse unmarshal: %w", err)
-	}
-
-	if len(response.Errors) > 0 {
-		first := response.Errors[0]
-		if first.RawMessage() == internalUpdateNotFoundMessage ||
-			first.RawMessage() == internalDeleteNotFoundMessage {
-			return types.ErrNotFound
-		}
-		return fmt.Errorf("pql error: %s", first.RawMessage())
-	}
-
-	if err := json.Unmarshal(response.Data.Result, into); err != nil {
-		return fmt.Errorf("json data result unmarshal: %w", err)
+	if err := parseResponse(body, into); err != nil {
+		return err
 	}
 
 	logger.Debug.Printf("[timing] request unmarshal took %s", time.Since(startParse))
@@ -133,27 +117,67 @@ func (e *DataProxyEngine) Do(ctx context.Context, payload interface{}, into inte
 }
 
 func (e *DataProxyEngine) Batch(ctx context.Context, payload interface{}, into interface{}) error {
-	data, err := json.Marshal(payload)
+	body, err := e.rawRequest(ctx, payload)
 	if err != nil {
-		return fmt.Errorf("payload marshal: %w", err)
+		return fmt.Errorf("request failed: %w", err)
 	}
 
-	body, err := e.retryableRequest(ctx, "POST", "/graphql", data)
+	return parseBatchResponse(body, into)
+}
+
+// rawRequest sends a query or batch and returns the response body as sent by the data proxy, see RecordingEngine
+func (e *DataProxyEngine) rawRequest(ctx context.Context, payload interface{}) ([]byte, error) {
+	data, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("payload marshal: %w", err)
 	}
 
-	if err := json.Unmarshal(body, &into); err != nil {
-		return fmt.Errorf("json body unmarshal: %w", err)
-	}
+	return e.retryableRequest(ctx, "POST", "/graphql", data)
+}
 
+func (e *DataProxyEngine) StartTx(context.Context, protocol.TransactionStartRequest) (string, error) {
+	return "", fmt.Errorf("interactive transactions are not supported by the data proxy engine")
+}
+
+func (e *DataProxyEngine) CommitTx(context.Context, string) error {
+	return fmt.Errorf("interactive transactions are not supported by the data proxy engine")
+}
+
+func (e *DataProxyEngine) RollbackTx(context.Context, string) error {
+	return fmt.Errorf("interactive transactions are not supported by the data proxy engine")
+}
+
+func (e *DataProxyEngine) Metrics(context.Context, MetricsFormat) ([]byte, error) {
+	return nil, fmt.Errorf("metrics are not supported by the data proxy engine")
+}
+
+// Ping checks that the client was connected to the data proxy. The data proxy is only reached by sending a query.
+func (e *DataProxyEngine) Ping(context.Context) error {
+	if e.url == "" {
+		return fmt.Errorf("data proxy engine is not connected")
+	}
 	return nil
 }
 
+func (e *DataProxyEngine) Status() Status {
+	if e.url == "" {
+		return Status{State: StateNotConnected}
+	}
+	return Status{State: StateConnected}
+}
+
 func (e *DataProxyEngine) Name() string {
 	return "data-proxy"
 }
 
+func (e *DataProxyEngine) Protocol() Protocol {
+	return ProtocolGraphQL
+}
+
+func (e *DataProxyEngine) TracerProvider() trace.TracerProvider {
+	return noop.NewTracerProvider()
+}
+
 func (e *DataProxyEngine) request(ctx context.Context, method string, path string, payload []byte) ([]byte, error) {
 	logger.Debug.Printf("requesting %s", e.url+path)
 	auth := func(req *http.Request) {
@@ -4,15 +4,54 @@ import (
 	"net/http"
 	"os/exec"
 	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
-func NewQueryEngine(schema string, hasBinaryTargets bool, datasources string, datasourceURL string) *QueryEngine {
-	return &QueryEngine{
+func NewQueryEngine(schema string, hasBinaryTargets bool, datasources string, datasourceURL string, options ...QueryEngineOption) *QueryEngine {
+	e := &QueryEngine{
 		Schema:           schema,
 		hasBinaryTargets: hasBinaryTargets,
 		datasources:      datasources,
 		datasourceURL:    datasourceURL,
 		http:             &http.Client{},
+		transport:        defaultTransport(),
+		engineProtocol:   ProtocolGraphQL,
+		stop:             make(chan struct{}),
+	}
+	for _, option := range options {
+		option(e)
+	}
+	return e
+}
+
+// QueryEngineOption configures a QueryEngine
+type QueryEngineOption func(*QueryEngine)
+
+// WithEventHandler registers a handler which is called on query engine lifecycle events
+func WithEventHandler(handler func(Event)) QueryEngineOption {
+	return func(e *QueryEngine) {
+		e.onEvent = handler
+	}
+}
+
+// Protocol describes the wire format which is used to send queries to the query engine
+type Protocol string
+
+const (
+	// ProtocolGraphQL sends queries as GraphQL documents
+	ProtocolGraphQL Protocol = "graphql"
+	// ProtocolJSON sends queries as structured JSON documents
+	ProtocolJSON Protocol = "json"
+)
+
+// WithProtocol sets the protocol which is used to send queries to the query engine. Defaults to ProtocolGraphQL.
+func WithProtocol(p Protocol) QueryEngineOption {
+	return func(e *QueryEngine) {
+		if p != "" {
+			e.engineProtocol = p
+		}
 	}
 }
 
@@ -23,6 +62,33 @@ type QueryEngine struct {
 	// cmd holds the prisma binary process
 	cmd *exec.Cmd
 
+	// file holds the path of the query engine binary, which is used to restart the engine
+	file string
+
+	// version holds the version of the query engine binary
+	version string
+
+	// startedAt is the time the current query engine process became ready
+	startedAt time.Time
+
+	// exited is closed when the current query engine process exits
+	exited chan struct{}
+
+	// exitErr contains the exit error of the last query engine process
+	exitErr error
+
+	// crashErr is set while the query engine is crashed or being restarted
+	crashErr *EngineCrashedError
+
+	// ready is closed when the query engine is running again after a crash, or when restarting stopped
+	ready chan struct{}
+
+	// stop is closed on Disconnect() to stop restarting a crashed query engine
+	stop chan struct{}
+
+	// onEvent is called on query engine lifecycle events
+	onEvent func(Event)
+
 	// http is the internal http client
 	http *http.Client
 
@@ -35,6 +101,31 @@ type QueryEngine struct {
 	// httpURL holds the query-engine httpURL
 	httpURL string
 
+	// engineURL is set when connecting to an externally managed query engine instead of starting one
+	engineURL string
+
+	// transport describes whether the query engine listens on a tcp port or a unix socket
+	transport Transport
+
+	// socket holds the unix socket path when using TransportUnix
+	socket string
+
+	// engineProtocol describes the wire format of queries
+	engineProtocol Protocol
+
+	// onLog is called on query engine log events of the levels in logLevels, or of all levels if empty
+	onLog     func(LogEvent)
+	logLevels map[LogLevel]bool
+
+	// slowQueryThreshold is the minimum duration of query log events
+	slowQueryThreshold time.Duration
+
+	// tracerProvider is set when OpenTelemetry tracing is enabled
+	tracerProvider trace.TracerProvider
+
+	// metrics is set when the query engine is started with metrics enabled
+	metrics bool
+
 	// hasBinaryTargets can be toggled by generated code from Schema.prisma whether binaryTargets
 	// were specified and thus expects binaries in the local path
 	hasBinaryTargets bool
@@ -45,15 +136,12 @@ type QueryEngine struct {
 	// disconnected indicates whether the user has called Disconnect()
 	disconnected bool
 
-	// closed keeps track of query engine status
-	closed chan interface{}
-
-	// onEngineError is a listener on the query engine stderr output
-	onEngineError chan string
-
 	// lastEngineError contains the last received error
 	lastEngineError string
 
+	// panicErr is set when the query engine printed a panic
+	panicErr *EnginePanicError
+
 	mu sync.RWMutex
 }
 
@@ -61,6 +149,10 @@ func (e *QueryEngine) Name() string {
 	return "query-engine"
 }
 
+func (e *QueryEngine) Protocol() Protocol {
+	return e.engineProtocol
+}
+
 // deprecated
 func (e *QueryEngine) ReplaceSchema(replace func(schema string) string) {
 	e.Schema = replace(e.Schema)
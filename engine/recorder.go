@@ -0,0 +1,665 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/steebchen/prisma-client-go/engine/protocol"
+	"github.com/steebchen/prisma-client-go/runtime/types"
+)
+
+// RecordingMode describes whether a RecordingEngine records requests or replays them
+type RecordingMode string
+
+const (
+	// RecordingModeRecord sends requests to the query engine and records them with their responses
+	RecordingModeRecord RecordingMode = "record"
+	// RecordingModeReplay serves recorded responses without starting a query engine or connecting to a database
+	RecordingModeReplay RecordingMode = "replay"
+)
+
+// ErrUnknownRequest is returned in replay mode for requests which were not recorded
+var ErrUnknownRequest = errors.New("request was not recorded")
+
+// kinds of recorded requests
+const (
+	interactionQuery    = "query"
+	interactionBatch    = "batch"
+	interactionStartTx  = "startTx"
+	interactionCommit   = "commitTx"
+	interactionRollback = "rollbackTx"
+)
+
+// recording is the content of a recording file
+type recording struct {
+	Protocol     Protocol      `json:"protocol"`
+	Interactions []interaction `json:"interactions"`
+}
+
+// interaction is a recorded request with its response
+type interaction struct {
+	Kind     string          `json:"kind"`
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	// UserFacingError contains the code and details of Prisma errors, so they are replayed as typed errors
+	UserFacingError *protocol.UserFacingError `json:"userFacingError,omitempty"`
+
+	// used is set once the interaction was replayed
+	used bool
+}
+
+// setError records a failed request
+func (i *interaction) setError(err error) {
+	i.Error = err.Error()
+	var ufe *protocol.UserFacingError
+	if errors.As(err, &ufe) {
+		i.UserFacingError = ufe
+	}
+}
+
+// err returns the recorded error of a failed request, or nil
+func (i interaction) err() error {
+	if i.Error == "" {
+		return nil
+	}
+	replayed := &replayedError{message: i.Error}
+	if i.UserFacingError != nil {
+		replayed.err = types.NewError(i.UserFacingError)
+	}
+	return replayed
+}
+
+// replayedError has the message of a recorded error, and wraps the typed error of recorded Prisma errors
+type replayedError struct {
+	message string
+	err     error
+}
+
+func (e *replayedError) Error() string {
+	return e.message
+}
+
+func (e *replayedError) Unwrap() error {
+	return e.err
+}
+
+// rawEngine is implemented by engines which can return the raw response of a query or batch
+type rawEngine interface {
+	rawRequest(ctx context.Context, payload interface{}) ([]byte, error)
+}
+
+// NewRecordingEngine returns an engine which records all requests sent to e and their raw responses to the file at
+// path, or which replays the responses recorded in that file, depending on mode. Recording requires e to be a query
+// engine or a data proxy engine. Replaying doesn't use e to send requests.
+func NewRecordingEngine(e Engine, path string, mode RecordingMode) *RecordingEngine {
+	return &RecordingEngine{
+		Engine: e,
+		path:   path,
+		mode:   mode,
+	}
+}
+
+// RecordingEngine records requests and responses to a file, or replays them, e.g. for deterministic tests which don't
+// need a database. Requests are matched by their payload, regardless of the order of object fields and of whitespace
+// in queries. Equal requests are replayed in the order in which they were recorded.
+type RecordingEngine struct {
+	// Engine is the engine which is recorded
+	Engine
+
+	path string
+	mode RecordingMode
+
+	recording recording
+	connected bool
+	// disconnected is set after Disconnect was called in replay mode
+	disconnected bool
+
+	mu sync.Mutex
+}
+
+// Mode returns whether the engine records or replays requests
+func (e *RecordingEngine) Mode() RecordingMode {
+	return e.mode
+}
+
+// Path returns the path of the recording file
+func (e *RecordingEngine) Path() string {
+	return e.path
+}
+
+// Connect connects the recorded engine, or loads the recording file in replay mode
+func (e *RecordingEngine) Connect() error {
+	if e.mode == RecordingModeReplay {
+		data, err := os.ReadFile(e.path)
+		if err != nil {
+			return fmt.Errorf("load recording: %w", err)
+		}
+
+		var r recording
+		if err := json.Unmarshal(data, &r); err != nil {
+			return fmt.Errorf("recording %s unmarshal: %w", e.path, err)
+		}
+
+		// requests are indented in the file
+		for n, i := range r.Interactions {
+			if r.Interactions[n].Request, err = normalizeRequest(i.Request); err != nil {
+				return fmt.Errorf("recording %s: %w", e.path, err)
+			}
+		}
+
+		e.mu.Lock()
+		e.recording = r
+		e.connected = true
+		e.mu.Unlock()
+
+		return nil
+	}
+
+	if _, ok := e.Engine.(rawEngine); !ok {
+		return fmt.Errorf("recording is not supported by the %s engine", e.Engine.Name())
+	}
+
+	e.mu.Lock()
+	e.recording = recording{Protocol: e.Engine.Protocol()}
+	e.mu.Unlock()
+
+	return e.Engine.Connect()
+}
+
+// Disconnect disconnects the recorded engine and writes the recording file
+func (e *RecordingEngine) Disconnect() error {
+	if e.mode == RecordingModeReplay {
+		e.mu.Lock()
+		e.connected = false
+		e.disconnected = true
+		e.mu.Unlock()
+		return nil
+	}
+
+	disconnectErr := e.Engine.Disconnect()
+	return errors.Join(disconnectErr, e.Save())
+}
+
+// Save writes the requests which were recorded so far to the recording file
+func (e *RecordingEngine) Save() error {
+	if e.mode != RecordingModeRecord {
+		return nil
+	}
+
+	e.mu.Lock()
+	data, err := json.MarshalIndent(e.recording, "", "  ")
+	e.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("recording marshal: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(e.path), 0755); err != nil {
+		return fmt.Errorf("create recording directory: %w", err)
+	}
+
+	if err := os.WriteFile(e.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("write recording: %w", err)
+	}
+
+	return nil
+}
+
+func (e *RecordingEngine) Do(ctx context.Context, payload interface{}, into interface{}) error {
+	body, err := e.roundTrip(ctx, interactionQuery, payload)
+	if err != nil {
+		return err
+	}
+
+	body, err = decodeResponse(e.protocol(), body)
+	if err != nil {
+		return err
+	}
+
+	return parseResponse(body, into)
+}
+
+func (e *RecordingEngine) Batch(ctx context.Context, payload interface{}, into interface{}) error {
+	body, err := e.roundTrip(ctx, interactionBatch, payload)
+	if err != nil {
+		return err
+	}
+
+	body, err = decodeResponse(e.protocol(), body)
+	if err != nil {
+		return err
+	}
+
+	return parseBatchResponse(body, into)
+}
+
+// roundTrip sends a query or batch and records its raw response, or returns the recorded response
+func (e *RecordingEngine) roundTrip(ctx context.Context, kind string, payload interface{}) ([]byte, error) {
+	request, err := normalizeRequest(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.mode == RecordingModeReplay {
+		i, err := e.replay(kind, request)
+		if err != nil {
+			return nil, err
+		}
+		if err := i.err(); err != nil {
+			return nil, err
+		}
+		return i.Response, nil
+	}
+
+	i := interaction{
+		Kind:    kind,
+		Request: request,
+	}
+	body, err := e.Engine.(rawEngine).rawRequest(ctx, payload)
+	if err != nil {
+		err = fmt.Errorf("request failed: %w", err)
+		i.setError(err)
+	} else {
+		i.Response = body
+	}
+	e.record(i)
+
+	return body, err
+}
+
+func (e *RecordingEngine) StartTx(ctx context.Context, options protocol.TransactionStartRequest) (string, error) {
+	request, err := normalizeRequest(options)
+	if err != nil {
+		return "", err
+	}
+
+	if e.mode == RecordingModeReplay {
+		i, err := e.replay(interactionStartTx, request)
+		if err != nil {
+			return "", err
+		}
+		if err := i.err(); err != nil {
+			return "", err
+		}
+		var response protocol.TransactionStartResponse
+		if err := json.Unmarshal(i.Response, &response); err != nil {
+			return "", fmt.Errorf("json transaction response unmarshal: %w", err)
+		}
+		return response.ID, nil
+	}
+
+	id, err := e.Engine.StartTx(ctx, options)
+	i := interaction{
+		Kind:    interactionStartTx,
+		Request: request,
+	}
+	if err != nil {
+		i.setError(err)
+	} else {
+		i.Response, _ = json.Marshal(protocol.TransactionStartResponse{ID: id})
+	}
+	e.record(i)
+
+	return id, err
+}
+
+func (e *RecordingEngine) CommitTx(ctx context.Context, id string) error {
+	return e.endTx(interactionCommit, id, func() error {
+		return e.Engine.CommitTx(ctx, id)
+	})
+}
+
+func (e *RecordingEngine) RollbackTx(ctx context.Context, id string) error {
+	return e.endTx(interactionRollback, id, func() error {
+		return e.Engine.RollbackTx(ctx, id)
+	})
+}
+
+// endTx commits or rolls back an interactive transaction and records the result
+func (e *RecordingEngine) endTx(kind string, id string, fn func() error) error {
+	request, err := normalizeRequest(map[string]string{"id": id})
+	if err != nil {
+		return err
+	}
+
+	if e.mode == RecordingModeReplay {
+		i, err := e.replay(kind, request)
+		if err != nil {
+			return err
+		}
+		return i.err()
+	}
+
+	err = fn()
+	i := interaction{
+		Kind:    kind,
+		Request: request,
+	}
+	if err != nil {
+		i.setError(err)
+	}
+	e.record(i)
+
+	return err
+}
+
+// Metrics returns the metrics of the recorded engine. Metrics are not recorded, so they are not available when
+// replaying.
+func (e *RecordingEngine) Metrics(ctx context.Context, format MetricsFormat) ([]byte, error) {
+	if e.mode == RecordingModeReplay {
+		return nil, fmt.Errorf("metrics are not available when replaying a recording")
+	}
+	return e.Engine.Metrics(ctx, format)
+}
+
+// Ping checks the recorded engine, or that the recording was loaded in replay mode
+func (e *RecordingEngine) Ping(ctx context.Context) error {
+	if e.mode != RecordingModeReplay {
+		return e.Engine.Ping(ctx)
+	}
+	if status := e.Status(); status.State != StateConnected {
+		return fmt.Errorf("recording engine is %s", status.State)
+	}
+	return nil
+}
+
+func (e *RecordingEngine) Status() Status {
+	if e.mode != RecordingModeReplay {
+		return e.Engine.Status()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	switch {
+	case e.disconnected:
+		return Status{State: StateDisconnected}
+	case e.connected:
+		return Status{State: StateConnected}
+	}
+	return Status{State: StateNotConnected}
+}
+
+// protocol returns the protocol which the responses were recorded in
+func (e *RecordingEngine) protocol() Protocol {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.recording.Protocol
+}
+
+func (e *RecordingEngine) record(i interaction) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.recording.Interactions = append(e.recording.Interactions, i)
+}
+
+// replay returns the first recorded interaction for the given request which was not replayed yet
+func (e *RecordingEngine) replay(kind string, request json.RawMessage) (interaction, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.connected {
+		return interaction{}, fmt.Errorf("client is not connected yet")
+	}
+
+	for n := range e.recording.Interactions {
+		i := &e.recording.Interactions[n]
+		if i.used || i.Kind != kind || !bytes.Equal(i.Request, request) {
+			continue
+		}
+		i.used = true
+		return *i, nil
+	}
+
+	return interaction{}, e.unknown(kind, request)
+}
+
+// unknown describes a request which was not recorded, with its differences to the closest recorded request
+func (e *RecordingEngine) unknown(kind string, request json.RawMessage) error {
+	actual := describeRequest(request)
+
+	var closest []string
+	var closestUsed bool
+	best := -1
+	for _, i := range e.recording.Interactions {
+		if i.Kind != kind {
+			continue
+		}
+		diff := diffLines(describeRequest(i.Request), actual)
+		changes := 0
+		for _, line := range diff {
+			if !strings.HasPrefix(line, "  ") {
+				changes++
+			}
+		}
+		if best == -1 || changes < best {
+			best = changes
+			closest = diff
+			closestUsed = i.used
+		}
+	}
+
+	msg := fmt.Sprintf("%s in %s", kind, e.path)
+	switch {
+	case closest == nil:
+		msg += ":\n\t" + strings.Join(actual, "\n\t")
+	case best == 0 && closestUsed:
+		msg += " was sent more often than recorded"
+	default:
+		msg += " differs from the closest recorded request (-recorded +actual):\n\t" + strings.Join(closest, "\n\t")
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnknownRequest, msg)
+}
+
+// normalizeRequest converts a payload to JSON with sorted object fields and without redundant whitespace in queries,
+// so requests can be compared regardless of how they were built
+func normalizeRequest(payload interface{}) (json.RawMessage, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("payload marshal: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, fmt.Errorf("payload unmarshal: %w", err)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(normalizeValue(v)); err != nil {
+		return nil, fmt.Errorf("payload marshal: %w", err)
+	}
+
+	return bytes.TrimSpace(buf.Bytes()), nil
+}
+
+func normalizeValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if query, ok := value.(string); ok && key == "query" {
+				v[key] = compactQuery(query)
+				continue
+			}
+			v[key] = normalizeValue(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = normalizeValue(value)
+		}
+	}
+	return v
+}
+
+// compactQuery removes whitespace outside of strings in a GraphQL query, apart from single spaces between names
+func compactQuery(query string) string {
+	var b strings.Builder
+	var last byte
+	inString, space := false, false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if inString {
+			b.WriteByte(c)
+			switch c {
+			case '\\':
+				if i+1 < len(query) {
+					i++
+					b.WriteByte(query[i])
+				}
+			case '"':
+				inString = false
+			}
+			continue
+		}
+		if isWhitespace(c) {
+			space = true
+			continue
+		}
+		if space && b.Len() > 0 && !isPunctuation(last) && !isPunctuation(c) {
+			b.WriteByte(' ')
+		}
+		space = false
+		last = c
+		if c == '"' {
+			inString = true
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func isPunctuation(c byte) bool {
+	return strings.IndexByte("{}()[]:,\"", c) >= 0
+}
+
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\t' || c == '\r'
+}
+
+// describeRequest formats a normalized request as lines for a diff, with GraphQL queries split into their fields
+func describeRequest(request json.RawMessage) []string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, request, "", "  "); err != nil {
+		return []string{string(request)}
+	}
+
+	var lines []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, `"query": "`) {
+			lines = append(lines, line)
+			continue
+		}
+		var query string
+		if err := json.Unmarshal([]byte(strings.TrimSuffix(strings.TrimPrefix(trimmed, `"query": `), ",")), &query); err != nil {
+			lines = append(lines, line)
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " "))]
+		lines = append(lines, indent+`"query":`)
+		for _, part := range splitQuery(query) {
+			lines = append(lines, indent+"  "+part)
+		}
+	}
+	return lines
+}
+
+// splitQuery splits a GraphQL query into lines after opening braces and commas and before closing braces
+func splitQuery(query string) []string {
+	var lines []string
+	var b strings.Builder
+	flush := func() {
+		if line := strings.TrimSpace(b.String()); line != "" {
+			lines = append(lines, line)
+		}
+		b.Reset()
+	}
+
+	inString := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if inString {
+			b.WriteByte(c)
+			switch c {
+			case '\\':
+				if i+1 < len(query) {
+					i++
+					b.WriteByte(query[i])
+				}
+			case '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+			b.WriteByte(c)
+		case '{', ',':
+			b.WriteByte(c)
+			flush()
+		case '}':
+			flush()
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	flush()
+
+	return lines
+}
+
+// diffLines returns the lines of both texts, with removed lines prefixed with "- ", added lines with "+ " and
+// unchanged lines with "  "
+func diffLines(a, b []string) []string {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var diff []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			diff = append(diff, "  "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, "- "+a[i])
+			i++
+		default:
+			diff = append(diff, "+ "+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, "- "+a[i])
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, "+ "+b[j])
+	}
+	return diff
+}
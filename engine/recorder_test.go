@@ -0,0 +1,173 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/steebchen/prisma-client-go/engine/protocol"
+	"github.com/steebchen/prisma-client-go/runtime/types"
+)
+
+// rawFakeEngine answers every query with the number of queries sent so far
+type rawFakeEngine struct {
+	Engine
+	queries int
+	calls   []string
+}
+
+func (e *rawFakeEngine) Connect() error {
+	e.calls = append(e.calls, "connect")
+	return nil
+}
+
+func (e *rawFakeEngine) Disconnect() error {
+	e.calls = append(e.calls, "disconnect")
+	return nil
+}
+
+func (e *rawFakeEngine) Protocol() Protocol {
+	return ProtocolGraphQL
+}
+
+func (e *rawFakeEngine) StartTx(ctx context.Context, options protocol.TransactionStartRequest) (string, error) {
+	e.calls = append(e.calls, "start")
+	return "tx-1", nil
+}
+
+func (e *rawFakeEngine) CommitTx(ctx context.Context, id string) error {
+	e.calls = append(e.calls, "commit "+id)
+	return nil
+}
+
+func (e *rawFakeEngine) RollbackTx(ctx context.Context, id string) error {
+	e.calls = append(e.calls, "rollback "+id)
+	return fmt.Errorf("request failed: %w", types.NewError(&protocol.UserFacingError{
+		Message:   "Transaction already closed",
+		ErrorCode: "P2028",
+	}))
+}
+
+func (e *rawFakeEngine) rawRequest(ctx context.Context, payload interface{}) ([]byte, error) {
+	switch p := payload.(type) {
+	case protocol.GQLBatchRequest:
+		return []byte(`{"batchResult":[{"data":{"result":{"id":"a"}}},{"errors":[{"error":"boom"}]}]}`), nil
+	case protocol.GQLRequest:
+		if strings.Contains(p.Query, "fail") {
+			return []byte(`{"errors":[{"error":"boom"}]}`), nil
+		}
+		if strings.Contains(p.Query, "unreachable") {
+			return nil, errors.New("raw post: connection refused")
+		}
+	}
+	e.queries++
+	return []byte(fmt.Sprintf(`{"data":{"result":{"count":%d}}}`, e.queries)), nil
+}
+
+type countResult struct {
+	Count int `json:"count"`
+}
+
+func gqlRequest(query string) protocol.GQLRequest {
+	return protocol.GQLRequest{Query: query, Variables: map[string]interface{}{}}
+}
+
+// sendRecordedRequests sends the same requests in record and replay mode
+func sendRecordedRequests(t *testing.T, e Engine, query string) {
+	t.Helper()
+	ctx := context.Background()
+
+	var first, second countResult
+	assert.NoError(t, e.Do(ctx, gqlRequest(query), &first))
+	assert.NoError(t, e.Do(ctx, gqlRequest(query), &second))
+	assert.Equal(t, countResult{Count: 1}, first)
+	assert.Equal(t, countResult{Count: 2}, second)
+
+	err := e.Do(ctx, gqlRequest(`query {result: fail}`), &first)
+	assert.EqualError(t, err, "internal error: boom")
+
+	// failed requests are recorded as well
+	err = e.Do(ctx, gqlRequest(`query {result: unreachable}`), &first)
+	assert.EqualError(t, err, "request failed: raw post: connection refused")
+
+	var batch protocol.GQLBatchResponse
+	assert.NoError(t, e.Batch(ctx, protocol.GQLBatchRequest{
+		Batch: []protocol.GQLRequest{gqlRequest("a"), gqlRequest("b")},
+	}, &batch))
+	assert.Len(t, batch.Result, 2)
+	assert.JSONEq(t, `{"id":"a"}`, string(batch.Result[0].Data.Result))
+	assert.EqualError(t, BatchError(batch), "internal error: boom")
+
+	id, err := e.StartTx(ctx, protocol.TransactionStartRequest{MaxWait: 2000, Timeout: 5000})
+	assert.NoError(t, err)
+	assert.Equal(t, "tx-1", id)
+	assert.NoError(t, e.CommitTx(ctx, id))
+
+	// Prisma errors are replayed as typed errors
+	err = e.RollbackTx(ctx, id)
+	assert.EqualError(t, err, "request failed: Transaction already closed")
+	var prismaErr *types.PrismaError
+	assert.ErrorAs(t, err, &prismaErr)
+	assert.Equal(t, "P2028", prismaErr.Code)
+}
+
+func TestRecordingEngine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recordings", "test.json")
+
+	fake := &rawFakeEngine{}
+	recorder := NewRecordingEngine(fake, path, RecordingModeRecord)
+	assert.NoError(t, recorder.Connect())
+	sendRecordedRequests(t, recorder, `query {result: findUniqueUser(where:{id:"a",}) {id }}`)
+	assert.NoError(t, recorder.Disconnect())
+	assert.Equal(t, []string{"connect", "start", "commit tx-1", "rollback tx-1", "disconnect"}, fake.calls)
+
+	// the recorded engine isn't used when replaying
+	replayer := NewRecordingEngine(nil, path, RecordingModeReplay)
+	assert.Equal(t, StateNotConnected, replayer.Status().State)
+	assert.NoError(t, replayer.Connect())
+	assert.NoError(t, replayer.Ping(context.Background()))
+	sendRecordedRequests(t, replayer, "query {\n  result: findUniqueUser(where: {id:\"a\",}) {\n    id\n  }\n}")
+	assert.NoError(t, replayer.Disconnect())
+	assert.Equal(t, StateDisconnected, replayer.Status().State)
+}
+
+func TestRecordingEngineUnknownRequest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.json")
+	ctx := context.Background()
+
+	recorder := NewRecordingEngine(&rawFakeEngine{}, path, RecordingModeRecord)
+	assert.NoError(t, recorder.Connect())
+	var result countResult
+	assert.NoError(t, recorder.Do(ctx, gqlRequest(`query {result: findUniqueUser(where:{id:"a",}) {id }}`), &result))
+	assert.NoError(t, recorder.Disconnect())
+
+	replayer := NewRecordingEngine(nil, path, RecordingModeReplay)
+	assert.NoError(t, replayer.Connect())
+
+	err := replayer.Do(ctx, gqlRequest(`query {result: findUniqueUser(where:{id:"b",}) {id }}`), &result)
+	assert.True(t, errors.Is(err, ErrUnknownRequest))
+	assert.Contains(t, err.Error(), "query in "+path+" differs from the closest recorded request (-recorded +actual):")
+	assert.Contains(t, err.Error(), "\t-     id:\"a\",\n\t+     id:\"b\",\n")
+
+	assert.NoError(t, replayer.Do(ctx, gqlRequest(`query {result: findUniqueUser(where:{id:"a",}) {id }}`), &result))
+	err = replayer.Do(ctx, gqlRequest(`query {result: findUniqueUser(where:{id:"a",}) {id }}`), &result)
+	assert.True(t, errors.Is(err, ErrUnknownRequest))
+	assert.Contains(t, err.Error(), "was sent more often than recorded")
+}
+
+func TestRecordingEngineMissingFile(t *testing.T) {
+	replayer := NewRecordingEngine(nil, filepath.Join(t.TempDir(), "missing.json"), RecordingModeReplay)
+	assert.ErrorContains(t, replayer.Connect(), "load recording")
+}
+
+func TestCompactQuery(t *testing.T) {
+	assert.Equal(t,
+		`query{result:findUniqueUser(where:{name:"a  b\" c",}){id name}}`,
+		compactQuery("query {\n  result: findUniqueUser(where: {name:\"a  b\\\" c\",})   {\n id\n name }\n}"),
+	)
+}
@@ -0,0 +1,234 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ReplicaStrategy describes how read queries are distributed across read replicas
+type ReplicaStrategy string
+
+const (
+	// ReplicaStrategyRoundRobin sends read queries to the replicas in turn
+	ReplicaStrategyRoundRobin ReplicaStrategy = "round-robin"
+	// ReplicaStrategyLeastLatency sends read queries to the replica with the lowest recent latency
+	ReplicaStrategyLeastLatency ReplicaStrategy = "least-latency"
+)
+
+// latencyDecay is the weight of the latest request when averaging the latency of a replica
+const latencyDecay = 0.2
+
+// errorPenalty is how long least-latency routing avoids a replica after a failed request
+const errorPenalty = 5 * time.Second
+
+type primaryKey struct{}
+
+// WithPrimary makes read queries sent with the returned context go to the primary, e.g. to read your own writes
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// IsPrimary returns whether ctx was marked with WithPrimary
+func IsPrimary(ctx context.Context) bool {
+	v, _ := ctx.Value(primaryKey{}).(bool)
+	return v
+}
+
+// NewReplicaEngine returns an engine which sends read queries to the replicas, and all other requests, such as
+// writes, raw queries and transactions, to the primary
+func NewReplicaEngine(primary Engine, replicas []Engine, strategy ReplicaStrategy) *ReplicaEngine {
+	if strategy == "" {
+		strategy = ReplicaStrategyRoundRobin
+	}
+	e := &ReplicaEngine{
+		Engine:   primary,
+		strategy: strategy,
+		penalty:  errorPenalty,
+	}
+	for _, r := range replicas {
+		e.replicas = append(e.replicas, &replica{Engine: r})
+	}
+	return e
+}
+
+// ReplicaEngine routes read queries to read replicas
+type ReplicaEngine struct {
+	// Engine is the primary engine
+	Engine
+
+	replicas []*replica
+	strategy ReplicaStrategy
+
+	// penalty is how long a replica is avoided after a failed request
+	penalty time.Duration
+
+	// next is the index of the next replica for round-robin routing
+	next atomic.Uint64
+}
+
+type replica struct {
+	Engine
+
+	// latency is the moving average of the duration of successful requests in nanoseconds
+	latency atomic.Int64
+
+	// failedUntil is the time in unix nanoseconds until which the replica is avoided after a failed request
+	failedUntil atomic.Int64
+}
+
+// Connect connects the primary and all replicas
+func (e *ReplicaEngine) Connect() error {
+	if err := e.Engine.Connect(); err != nil {
+		return err
+	}
+
+	for i, r := range e.replicas {
+		if err := r.Connect(); err != nil {
+			disconnectErr := e.disconnect(i)
+			return errors.Join(fmt.Errorf("connect read replica %d: %w", i, err), disconnectErr)
+		}
+	}
+
+	return nil
+}
+
+// Disconnect disconnects the primary and all replicas
+func (e *ReplicaEngine) Disconnect() error {
+	return e.disconnect(len(e.replicas))
+}
+
+// disconnect disconnects the primary and the first n replicas
+func (e *ReplicaEngine) disconnect(n int) error {
+	errs := make([]error, n+1)
+
+	var wg sync.WaitGroup
+	for i, r := range e.replicas[:n] {
+		wg.Add(1)
+		go func(i int, r *replica) {
+			defer wg.Done()
+			if err := r.Disconnect(); err != nil {
+				errs[i] = fmt.Errorf("disconnect read replica %d: %w", i, err)
+			}
+		}(i, r)
+	}
+
+	errs[n] = e.Engine.Disconnect()
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Ping checks the primary and all replicas
+func (e *ReplicaEngine) Ping(ctx context.Context) error {
+	errs := make([]error, len(e.replicas)+1)
+
+	var wg sync.WaitGroup
+	for i, r := range e.replicas {
+		wg.Add(1)
+		go func(i int, r *replica) {
+			defer wg.Done()
+			if err := r.Ping(ctx); err != nil {
+				errs[i] = fmt.Errorf("ping read replica %d: %w", i, err)
+			}
+		}(i, r)
+	}
+
+	errs[len(e.replicas)] = e.Engine.Ping(ctx)
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Do sends read queries to a replica and all other queries to the primary
+func (e *ReplicaEngine) Do(ctx context.Context, payload interface{}, into interface{}) error {
+	return e.route(ctx, payload, func(target Engine) error {
+		return target.Do(ctx, payload, into)
+	})
+}
+
+// Batch sends non-transactional batches of read queries to a replica and all other batches to the primary
+func (e *ReplicaEngine) Batch(ctx context.Context, payload interface{}, into interface{}) error {
+	return e.route(ctx, payload, func(target Engine) error {
+		return target.Batch(ctx, payload, into)
+	})
+}
+
+// route sends a request to a replica if possible, or to the primary otherwise
+func (e *ReplicaEngine) route(ctx context.Context, payload interface{}, send func(target Engine) error) error {
+	if !e.useReplica(ctx, payload) {
+		return send(e.Engine)
+	}
+
+	r := e.pick()
+
+	start := time.Now()
+	err := send(r.Engine)
+	switch {
+	case err == nil:
+		r.observe(time.Since(start))
+	case ctx.Err() == nil:
+		// failed requests may return quickly, so their duration would make the replica look fast. Instead, the
+		// replica is avoided for a while, and gets traffic again afterwards in case it recovered.
+		r.failedUntil.Store(time.Now().Add(e.penalty).UnixNano())
+	}
+
+	return err
+}
+
+// useReplica returns whether a request only reads data and is not part of an interactive transaction
+func (e *ReplicaEngine) useReplica(ctx context.Context, payload interface{}) bool {
+	if len(e.replicas) == 0 || IsPrimary(ctx) {
+		return false
+	}
+	if _, ok := TransactionID(ctx); ok {
+		return false
+	}
+	return !isTransactional(payload) && isReadOnly(payload)
+}
+
+func (e *ReplicaEngine) pick() *replica {
+	if e.strategy == ReplicaStrategyLeastLatency {
+		now := time.Now().UnixNano()
+		best := e.replicas[0]
+		for _, r := range e.replicas[1:] {
+			if r.less(best, now) {
+				best = r
+			}
+		}
+		return best
+	}
+
+	i := e.next.Add(1) - 1
+	return e.replicas[i%uint64(len(e.replicas))]
+}
+
+// less returns whether r should be preferred over other: replicas which failed recently are avoided, or the one
+// whose penalty ends first is used if all failed recently, and otherwise the one with the lowest latency
+func (r *replica) less(other *replica, now int64) bool {
+	failed, otherFailed := r.failedUntil.Load(), other.failedUntil.Load()
+	switch {
+	case failed > now && otherFailed > now:
+		return failed < otherFailed
+	case failed > now || otherFailed > now:
+		return otherFailed > now
+	}
+	return r.latency.Load() < other.latency.Load()
+}
+
+// observe adds the duration of a request to the moving average latency
+func (r *replica) observe(d time.Duration) {
+	for {
+		old := r.latency.Load()
+		updated := int64(d)
+		if old != 0 {
+			updated = int64(float64(old)*(1-latencyDecay) + float64(d)*latencyDecay)
+		}
+		if r.latency.CompareAndSwap(old, updated) {
+			return
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/steebchen/prisma-client-go/engine/protocol"
+)
+
+type recordingEngine struct {
+	Engine
+	name       string
+	calls      *[]string
+	connectErr error
+	doErr      error
+	// failures is the number of requests which fail before the engine recovers
+	failures *int
+	delay    time.Duration
+}
+
+func (e recordingEngine) Connect() error {
+	*e.calls = append(*e.calls, "connect "+e.name)
+	return e.connectErr
+}
+
+func (e recordingEngine) Disconnect() error {
+	return nil
+}
+
+func (e recordingEngine) Do(ctx context.Context, payload interface{}, into interface{}) error {
+	time.Sleep(e.delay)
+	*e.calls = append(*e.calls, e.name)
+	if e.failures != nil && *e.failures > 0 {
+		*e.failures--
+		return errors.New("connection refused")
+	}
+	return e.doErr
+}
+
+func (e recordingEngine) Batch(ctx context.Context, payload interface{}, into interface{}) error {
+	*e.calls = append(*e.calls, "batch "+e.name)
+	return nil
+}
+
+func TestReplicaEngine_Do(t *testing.T) {
+	read := protocol.GQLRequest{Query: "query { result: findUniqueUser { id } }"}
+	write := protocol.GQLRequest{Query: "mutation { result: createOneUser { id } }"}
+	raw := protocol.JSONRequest{Action: "queryRaw"}
+	findRaw := protocol.JSONRequest{Action: "findRaw"}
+
+	var calls []string
+	e := NewReplicaEngine(
+		recordingEngine{name: "primary", calls: &calls},
+		[]Engine{
+			recordingEngine{name: "replica 0", calls: &calls},
+			recordingEngine{name: "replica 1", calls: &calls},
+		},
+		"",
+	)
+
+	ctx := context.Background()
+	for _, payload := range []interface{}{read, read, read, write, raw, findRaw} {
+		assert.NoError(t, e.Do(ctx, payload, nil))
+	}
+	assert.NoError(t, e.Do(WithPrimary(ctx), read, nil))
+	assert.NoError(t, e.Do(WithTransactionID(ctx, "tx"), read, nil))
+	assert.NoError(t, e.Batch(ctx, protocol.GQLBatchRequest{Batch: []protocol.GQLRequest{read}, Transaction: &protocol.GQLTransaction{}}, nil))
+	assert.NoError(t, e.Batch(ctx, protocol.GQLBatchRequest{Batch: []protocol.GQLRequest{read}}, nil))
+
+	assert.Equal(t, []string{
+		"replica 0",
+		"replica 1",
+		"replica 0",
+		"primary",
+		"primary",
+		"replica 1",
+		"primary",
+		"primary",
+		"batch primary",
+		"batch replica 0",
+	}, calls)
+}
+
+func TestReplicaEngine_leastLatency(t *testing.T) {
+	read := protocol.GQLRequest{Query: "query { result: findUniqueUser { id } }"}
+
+	var calls []string
+	e := NewReplicaEngine(
+		recordingEngine{name: "primary", calls: &calls},
+		[]Engine{
+			recordingEngine{name: "slow", calls: &calls, delay: 20 * time.Millisecond},
+			recordingEngine{name: "fast", calls: &calls},
+		},
+		ReplicaStrategyLeastLatency,
+	)
+
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, e.Do(context.Background(), read, nil))
+	}
+
+	// each replica is tried once before its latency is known
+	assert.Equal(t, []string{"slow", "fast", "fast", "fast"}, calls)
+}
+
+func TestReplicaEngine_leastLatencyError(t *testing.T) {
+	read := protocol.GQLRequest{Query: "query { result: findUniqueUser { id } }"}
+
+	var calls []string
+	e := NewReplicaEngine(
+		recordingEngine{name: "primary", calls: &calls},
+		[]Engine{
+			recordingEngine{name: "failing", calls: &calls, doErr: errors.New("connection refused")},
+			recordingEngine{name: "slow", calls: &calls, delay: 20 * time.Millisecond},
+		},
+		ReplicaStrategyLeastLatency,
+	)
+
+	assert.Error(t, e.Do(context.Background(), read, nil))
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, e.Do(context.Background(), read, nil))
+	}
+
+	// the failing replica fails fast, but is avoided after the failed request
+	assert.Equal(t, []string{"failing", "slow", "slow", "slow"}, calls)
+}
+
+func TestReplicaEngine_leastLatencyRecovery(t *testing.T) {
+	read := protocol.GQLRequest{Query: "query { result: findUniqueUser { id } }"}
+
+	var calls []string
+	failures := 1
+	e := NewReplicaEngine(
+		recordingEngine{name: "primary", calls: &calls},
+		[]Engine{
+			recordingEngine{name: "flaky", calls: &calls, failures: &failures},
+			recordingEngine{name: "slow", calls: &calls, delay: 20 * time.Millisecond},
+		},
+		ReplicaStrategyLeastLatency,
+	)
+	e.penalty = 50 * time.Millisecond
+
+	assert.Error(t, e.Do(context.Background(), read, nil))
+	assert.NoError(t, e.Do(context.Background(), read, nil))
+
+	// the flaky replica gets traffic again once its penalty is over
+	time.Sleep(60 * time.Millisecond)
+	assert.NoError(t, e.Do(context.Background(), read, nil))
+	assert.NoError(t, e.Do(context.Background(), read, nil))
+
+	assert.Equal(t, []string{"flaky", "slow", "flaky", "flaky"}, calls)
+}
+
+func TestReplicaEngine_Connect(t *testing.T) {
+	errReplica := errors.New("replica failed")
+
+	var calls []string
+	e := NewReplicaEngine(
+		recordingEngine{name: "primary", calls: &calls},
+		[]Engine{
+			recordingEngine{name: "replica 0", calls: &calls},
+			recordingEngine{name: "replica 1", calls: &calls, connectErr: errReplica},
+		},
+		ReplicaStrategyRoundRobin,
+	)
+
+	err := e.Connect()
+
+	assert.ErrorIs(t, err, errReplica)
+	assert.Equal(t, []string{"connect primary", "connect replica 0", "connect replica 1"}, calls)
+}
+
+func TestReplicaEngine_envDatasource(t *testing.T) {
+	useFakeEngine(t)
+	t.Setenv("DATABASE_URL", "postgresql://primary")
+
+	// the schema reads the url of the primary from an env var
+	datasources := `[{"name":"db","provider":"postgresql","activeProvider":"postgresql","url":{"fromEnvVar":"DATABASE_URL","value":""}}]`
+	newEngine := func(url string) Engine {
+		return NewQueryEngine("", false, datasources, url, WithTransport(TransportTCP))
+	}
+
+	e := NewReplicaEngine(newEngine(""), []Engine{newEngine("postgresql://replica")}, "")
+	if err := e.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		assert.NoError(t, e.Disconnect())
+	}()
+
+	var result struct {
+		URL string `json:"url"`
+	}
+	ctx := context.Background()
+
+	assert.NoError(t, e.Do(ctx, protocol.GQLRequest{Query: "query { result: findUniqueUser { id } }"}, &result))
+	assert.Equal(t, "postgresql://replica", result.URL)
+
+	assert.NoError(t, e.Do(ctx, protocol.GQLRequest{Query: "mutation { result: createOneUser { id } }"}, &result))
+	assert.Equal(t, "postgresql://primary", result.URL)
+}
@@ -9,17 +9,13 @@ import (
 
 	"github.com/steebchen/prisma-client-go/engine/protocol"
 	"github.com/steebchen/prisma-client-go/logger"
-	"github.com/steebchen/prisma-client-go/runtime/types"
 )
 
-var internalUpdateNotFoundMessage = "Error occurred during query execution: InterpretationError(\"Error for binding '0'\", Some(QueryGraphBuilderError(RecordNotFound(\"Record to update not found.\"))))"
-var internalDeleteNotFoundMessage = "Error occurred during query execution: InterpretationError(\"Error for binding '0'\", Some(QueryGraphBuilderError(RecordNotFound(\"Record to delete does not exist.\"))))"
-
 // Do sends the http Request to the query engine and unmarshals the response
 func (e *QueryEngine) Do(ctx context.Context, payload interface{}, v interface{}) error {
 	startReq := time.Now()
 
-	body, err := e.Request(ctx, "POST", "/", payload, true)
+	body, err := e.tracedRequest(ctx, payload)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
@@ -29,32 +25,13 @@ func (e *QueryEngine) Do(ctx context.Context, payload interface{}, v interface{}
 
 	startParse := time.Now()
 
-	var response protocol.GQLResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return fmt.Errorf("json gql response unmarshal: %w", err)
-	}
-
-	if len(response.Errors) > 0 {
-		e := response.Errors[0]
-		if e.RawMessage() == internalUpdateNotFoundMessage ||
-			e.RawMessage() == internalDeleteNotFoundMessage {
-			return types.ErrNotFound
-		}
-
-		if e.UserFacingError != nil {
-			return fmt.Errorf("user facing error: %w", e.UserFacingError)
-		}
-
-		return fmt.Errorf("internal error: %s", e.RawMessage())
-	}
-
-	response.Data.Result, err = TransformResponse(response.Data.Result)
+	body, err = e.decode(body)
 	if err != nil {
-		return fmt.Errorf("transform response: %w", err)
+		return err
 	}
 
-	if err := json.Unmarshal(response.Data.Result, v); err != nil {
-		return fmt.Errorf("json data result unmarshal: %w", err)
+	if err := parseResponse(body, v); err != nil {
+		return err
 	}
 
 	logger.Debug.Printf("[timing] request unmarshaling took %s", time.Since(startParse))
@@ -62,27 +39,51 @@ func (e *QueryEngine) Do(ctx context.Context, payload interface{}, v interface{}
 	return nil
 }
 
-// Batch sends a batch request to the query engine; used for transactions
+// Batch sends a batch request to the query engine; used for transactions and batches
 func (e *QueryEngine) Batch(ctx context.Context, payload interface{}, v interface{}) error {
-	body, err := e.Request(ctx, "POST", "/", payload, true)
+	body, err := e.tracedRequest(ctx, payload)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
 
-	body, err = TransformResponse(body)
+	body, err = e.decode(body)
 	if err != nil {
-		return fmt.Errorf("transform response: %w", err)
+		return err
 	}
 
-	if err := json.Unmarshal(body, &v); err != nil {
-		return fmt.Errorf("json body unmarshal: %w", err)
+	return parseBatchResponse(body, v)
+}
+
+// decode converts JSON protocol responses to the GraphQL protocol response format
+func (e *QueryEngine) decode(body []byte) ([]byte, error) {
+	return decodeResponse(e.engineProtocol, body)
+}
+
+// decodeResponse converts responses of the given protocol to the GraphQL protocol response format
+func decodeResponse(p Protocol, body []byte) ([]byte, error) {
+	if p != ProtocolJSON {
+		return body, nil
 	}
+	body, err := protocol.DecodeJSONResponse(body)
+	if err != nil {
+		return nil, fmt.Errorf("decode json protocol response: %w", err)
+	}
+	return body, nil
+}
 
-	return nil
+// rawRequest sends a query or batch and returns the response body as sent by the query engine, see RecordingEngine
+func (e *QueryEngine) rawRequest(ctx context.Context, payload interface{}) ([]byte, error) {
+	return e.tracedRequest(ctx, payload)
 }
 
 func (e *QueryEngine) Request(ctx context.Context, method string, path string, payload interface{}, requiresConnection bool) ([]byte, error) {
-	if !e.connected && requiresConnection {
+	e.mu.RLock()
+	connected := e.connected
+	httpURL := e.httpURL
+	client := e.http
+	e.mu.RUnlock()
+
+	if !connected && requiresConnection {
 		logger.Info.Printf("A query was executed before Connect() was called. Make sure to call .Prisma.Connect() before sending any queries.")
 		return nil, fmt.Errorf("client is not connected yet")
 	}
@@ -100,7 +101,11 @@ func (e *QueryEngine) Request(ctx context.Context, method string, path string, p
 		return nil, fmt.Errorf("payload marshal: %w", err)
 	}
 
-	return request(ctx, e.http, method, e.httpURL+path, requestBody, func(req *http.Request) {
+	return request(ctx, client, method, httpURL+path, requestBody, func(req *http.Request) {
 		req.Header.Set("content-type", "application/json")
+		injectTraceParent(ctx, req)
+		if id, ok := TransactionID(ctx); ok {
+			req.Header.Set("X-transaction-id", id)
+		}
 	})
 }
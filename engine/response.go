@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/steebchen/prisma-client-go/engine/protocol"
+	"github.com/steebchen/prisma-client-go/runtime/types"
+)
+
+var internalUpdateNotFoundMessage = "Error occurred during query execution: InterpretationError(\"Error for binding '0'\", Some(QueryGraphBuilderError(RecordNotFound(\"Record to update not found.\"))))"
+var internalDeleteNotFoundMessage = "Error occurred during query execution: InterpretationError(\"Error for binding '0'\", Some(QueryGraphBuilderError(RecordNotFound(\"Record to delete does not exist.\"))))"
+
+// parseResponse unmarshals the response of a single query into v.
+// It is shared by all engines, so errors and raw query results are handled the same way.
+func parseResponse(body []byte, v interface{}) error {
+	var response protocol.GQLResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("json gql response unmarshal: %w", err)
+	}
+
+	if len(response.Errors) > 0 {
+		return ResponseError(response.Errors[0])
+	}
+
+	result, err := TransformResponse(response.Data.Result)
+	if err != nil {
+		return fmt.Errorf("transform response: %w", err)
+	}
+
+	if err := json.Unmarshal(result, v); err != nil {
+		return fmt.Errorf("json data result unmarshal: %w", err)
+	}
+
+	return nil
+}
+
+// parseBatchResponse unmarshals the response of a batch into v, which is usually a *protocol.GQLBatchResponse.
+// Errors are kept in the response, so callers can decide whether a single failed query fails the whole batch;
+// see BatchError. Raw query results are transformed per query when they are read.
+func parseBatchResponse(body []byte, v interface{}) error {
+	if err := json.Unmarshal(body, &v); err != nil {
+		return fmt.Errorf("json body unmarshal: %w", err)
+	}
+
+	return nil
+}
+
+// ResponseError converts an error of a query engine response into a typed error
+func ResponseError(e protocol.GQLError) error {
+	if e.Err != nil {
+		return e.Err
+	}
+
+	if e.RawMessage() == internalUpdateNotFoundMessage ||
+		e.RawMessage() == internalDeleteNotFoundMessage {
+		return types.ErrNotFound
+	}
+
+	// the query engine panicked while executing the query
+	if e.UserFacingError != nil && e.UserFacingError.IsPanic {
+		return &EnginePanicError{Message: e.UserFacingError.Message}
+	}
+
+	if e.UserFacingError != nil {
+		return fmt.Errorf("user facing error: %w", types.NewError(e.UserFacingError))
+	}
+
+	return fmt.Errorf("internal error: %s", e.RawMessage())
+}
+
+// BatchError returns the first error of a batch response, either of the batch itself or of one of its queries
+func BatchError(response protocol.GQLBatchResponse) error {
+	if len(response.Errors) > 0 {
+		return ResponseError(response.Errors[0])
+	}
+	for _, inner := range response.Result {
+		if len(inner.Errors) > 0 {
+			return ResponseError(inner.Errors[0])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/steebchen/prisma-client-go/engine/protocol"
+	"github.com/steebchen/prisma-client-go/runtime/types"
+)
+
+// standInResponses maps a keyword of a query to the response of the stand-in server
+var standInResponses = map[string]string{
+	"findUniqueUser": `{"data":{"result":{"id":"a"}}}`,
+	"queryRaw":       `{"data":{"result":{"columns":["id","count"],"types":["string","int"],"rows":[["a",1]]}}}`,
+	"findRaw":        `{"data":{"result":[{"_id":{"$oid":"67347ee4a18fa09750c1085a"}}]}}`,
+	"createOneUser":  `{"errors":[{"error":"Unique constraint failed","user_facing_error":{"is_panic":false,"message":"Unique constraint failed on the fields: (` + "`email`" + `)","meta":{"target":["email"]},"error_code":"P2002"}}]}`,
+	"updateOneUser":  `{"errors":[{"error":"Error occurred during query execution: InterpretationError(\"Error for binding '0'\", Some(QueryGraphBuilderError(RecordNotFound(\"Record to update not found.\"))))"}]}`,
+	"deleteOneUser":  `{"errors":[{"error":"something went wrong"}]}`,
+	"upsertOneUser":  `{"errors":[{"error":"PANIC: boom","user_facing_error":{"is_panic":true,"message":"boom","meta":{},"error_code":""}}]}`,
+}
+
+// standInServer answers query engine and data proxy requests the same way. Handler errors are reported when the
+// test finishes, as the handler doesn't run in the test goroutine.
+func standInServer(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	var errs []error
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fail := func(err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		respond := func(query string) (string, error) {
+			for keyword, response := range standInResponses {
+				if strings.Contains(query, keyword) {
+					return response, nil
+				}
+			}
+			return "", fmt.Errorf("unexpected query %s", query)
+		}
+
+		var batch protocol.GQLBatchRequest
+		if err := json.Unmarshal(body, &batch); err == nil && len(batch.Batch) > 0 {
+			results := make([]string, len(batch.Batch))
+			for i, q := range batch.Batch {
+				if results[i], err = respond(q.Query); err != nil {
+					fail(err)
+					return
+				}
+			}
+			_, _ = w.Write([]byte(`{"batchResult":[` + strings.Join(results, ",") + `]}`))
+			return
+		}
+
+		var request protocol.GQLRequest
+		if err := json.Unmarshal(body, &request); err != nil {
+			fail(err)
+			return
+		}
+		response, err := respond(request.Query)
+		if err != nil {
+			fail(err)
+			return
+		}
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(func() {
+		s.Close()
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, err := range errs {
+			t.Errorf("stand-in server: %s", err)
+		}
+	})
+	return s
+}
+
+// standInEngines returns a query engine and a data proxy engine, which both send requests to the stand-in server
+func standInEngines(t *testing.T) map[string]Engine {
+	s := standInServer(t)
+
+	qe := NewQueryEngine("", false, "", "")
+	qe.http = s.Client()
+	qe.httpURL = s.URL
+	qe.connected = true
+
+	proxy := NewDataProxyEngine("", "")
+	proxy.http = s.Client()
+	proxy.url = s.URL
+	proxy.apiKey = "key"
+
+	return map[string]Engine{
+		"query engine": qe,
+		"data proxy":   proxy,
+	}
+}
+
+func query(q string) protocol.GQLRequest {
+	return protocol.GQLRequest{Query: q, Variables: map[string]interface{}{}}
+}
+
+func TestEngines_Do(t *testing.T) {
+	for name, e := range standInEngines(t) {
+		e := e
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			var user struct {
+				ID string `json:"id"`
+			}
+			assert.NoError(t, e.Do(ctx, query(`query {result: findUniqueUser {id}}`), &user))
+			assert.Equal(t, "a", user.ID)
+
+			var rows json.RawMessage
+			assert.NoError(t, e.Do(ctx, query(`mutation {result: queryRaw}`), &rows))
+			assert.JSONEq(t, `[{"id":"a","count":1}]`, string(rows))
+
+			assert.NoError(t, e.Do(ctx, query(`query {result: findRaw}`), &rows))
+			assert.JSONEq(t, `[{"_id":"67347ee4a18fa09750c1085a","id":"67347ee4a18fa09750c1085a"}]`, string(rows))
+
+			err := e.Do(ctx, query(`mutation {result: createOneUser {id}}`), &user)
+			violation, ok := types.CheckUniqueConstraint[string](err)
+			assert.True(t, ok)
+			assert.Equal(t, []string{"email"}, violation.Fields)
+
+			err = e.Do(ctx, query(`mutation {result: updateOneUser {id}}`), &user)
+			assert.ErrorIs(t, err, types.ErrNotFound)
+
+			err = e.Do(ctx, query(`mutation {result: deleteOneUser {id}}`), &user)
+			assert.EqualError(t, err, "internal error: something went wrong")
+
+			err = e.Do(ctx, query(`mutation {result: upsertOneUser {id}}`), &user)
+			var panicErr *EnginePanicError
+			assert.ErrorAs(t, err, &panicErr)
+			assert.Equal(t, &EnginePanicError{Message: "boom"}, panicErr)
+		})
+	}
+}
+
+func TestEngines_Batch(t *testing.T) {
+	for name, e := range standInEngines(t) {
+		e := e
+		t.Run(name, func(t *testing.T) {
+			var response protocol.GQLBatchResponse
+			assert.NoError(t, e.Batch(context.Background(), protocol.GQLBatchRequest{
+				Batch: []protocol.GQLRequest{
+					query(`query {result: findUniqueUser {id}}`),
+					query(`query {result: findRaw}`),
+					query(`mutation {result: createOneUser {id}}`),
+				},
+			}, &response))
+
+			assert.Len(t, response.Result, 3)
+			assert.JSONEq(t, `{"id":"a"}`, string(response.Result[0].Data.Result))
+
+			// raw results are transformed per query
+			rows, err := TransformResponse(response.Result[1].Data.Result)
+			assert.NoError(t, err)
+			assert.JSONEq(t, `[{"_id":"67347ee4a18fa09750c1085a","id":"67347ee4a18fa09750c1085a"}]`, string(rows))
+
+			_, ok := types.CheckUniqueConstraint[string](BatchError(response))
+			assert.True(t, ok)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/steebchen/prisma-client-go/logger"
+)
+
+// WithEngineURL connects to a query engine which is already running at the given URL, e.g. in a sidecar container,
+// instead of starting a query engine process. The query engine binary is not needed in this case, and the engine
+// must be started with the same schema and its own datasource configuration.
+//
+// The query engine doesn't expose its schema, so it's not checked that it matches the client's schema. Connecting by
+// URL opts out of this check, and a warning is logged on Connect.
+func WithEngineURL(url string) QueryEngineOption {
+	return func(e *QueryEngine) {
+		e.engineURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// connectRemote waits until the externally managed query engine is ready
+func (e *QueryEngine) connectRemote() error {
+	logger.Debug.Printf("connecting to query engine at %s", e.engineURL)
+
+	start := time.Now()
+
+	e.mu.Lock()
+	e.httpURL = e.engineURL
+	e.http = &http.Client{}
+	e.mu.Unlock()
+
+	if err := e.awaitReadiness(nil); err != nil {
+		return err
+	}
+
+	logger.Warn.Printf("the schema of the query engine at %s is not checked; make sure it runs the schema the client was generated from", e.engineURL)
+
+	logger.Debug.Printf("connecting took %s", time.Since(start))
+
+	e.mu.Lock()
+	e.connected = true
+	e.mu.Unlock()
+
+	logger.Debug.Printf("connected.")
+
+	e.emit(Event{Type: EventConnected})
+
+	return nil
+}
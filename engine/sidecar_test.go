@@ -0,0 +1,43 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sidecarServer(t *testing.T, status string) *httptest.Server {
+	requests := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch {
+		case r.URL.Path == "/status" && requests == 1:
+			// the engine is not ready on the first request
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case r.URL.Path == "/status":
+			_, _ = w.Write([]byte(status))
+		default:
+			_, _ = w.Write([]byte(`{"data":{"result":{"id":"a"}}}`))
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestQueryEngine_WithEngineURL(t *testing.T) {
+	s := sidecarServer(t, `{"status":"ok"}`)
+	e := NewQueryEngine("model User { id String @id }", false, "", "", WithEngineURL(s.URL+"/"))
+
+	assert.NoError(t, e.Connect())
+
+	var user struct {
+		ID string `json:"id"`
+	}
+	assert.NoError(t, e.Do(context.Background(), query(`query {result: findUniqueUser {id}}`), &user))
+	assert.Equal(t, "a", user.ID)
+
+	assert.NoError(t, e.Disconnect())
+}
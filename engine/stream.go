@@ -4,61 +4,144 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os/exec"
+	"strings"
 
 	"github.com/steebchen/prisma-client-go/logger"
 )
 
+// maxStderrLineLength is the maximum length of a line of query engine output, e.g. a query log event with a large
+// query. Longer lines are skipped.
+const maxStderrLineLength = 16 * 1024 * 1024
+
 type Messsage struct {
-	IsPanic bool   `json:"is_panic"`
-	Message string `json:"message"`
+	IsPanic   bool   `json:"is_panic"`
+	Message   string `json:"message"`
+	Backtrace string `json:"backtrace"`
 }
 
-func (e *QueryEngine) streamStderr(cmd *exec.Cmd, onError chan<- string) error {
+// streamStderr reads the query engine output. The returned channel is closed once all output was read.
+func (e *QueryEngine) streamStderr(cmd *exec.Cmd) (<-chan struct{}, error) {
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
-		return fmt.Errorf("get stderr pipe: %w", err)
+		return nil, fmt.Errorf("get stderr pipe: %w", err)
 	}
 
-	go func() {
-	outer:
-		for {
-			select {
-			case v := <-e.onEngineError:
-				e.mu.Lock()
-				e.lastEngineError = v
-				e.mu.Unlock()
-			case <-e.closed:
-				logger.Debug.Printf("query engine closed")
-				break outer
-			}
-		}
-	}()
+	done := make(chan struct{})
 
 	go func() {
+		defer close(done)
+
 		scanner := bufio.NewScanner(stderr)
-		const maxCapacity int = 65536
-		buf := make([]byte, maxCapacity)
-		scanner.Buffer(buf, maxCapacity)
+		scanner.Buffer(make([]byte, 64*1024), maxStderrLineLength)
 
 		for scanner.Scan() {
-			contents := scanner.Bytes()
-			var message Messsage
-			if err := json.Unmarshal(contents, &message); err != nil {
-				log.Printf("failed to unmarshal message: %s", err.Error())
-				continue
-			}
-
-			if message.Message != "" {
-				onError <- message.Message
-				log.Println(message.Message)
-				continue
-			}
+			e.handleStderr(scanner.Bytes())
+		}
 
-			log.Println(string(contents))
+		if err := scanner.Err(); err != nil {
+			logger.Info.Printf("could not read query engine output: %s", err)
+			// keep draining the output, as the query engine blocks when the pipe is full
+			_, _ = io.Copy(io.Discard, stderr)
 		}
 	}()
 
-	return nil
+	return done, nil
+}
+
+// handleStderr classifies a line of query engine output. Panics and errors are recorded, so they can be returned
+// when the query engine fails to start or crashes.
+func (e *QueryEngine) handleStderr(line []byte) {
+	level, message, panicErr := classifyStderr(line)
+
+	switch {
+	case panicErr != nil:
+		e.mu.Lock()
+		e.panicErr = panicErr
+		e.lastEngineError = panicErr.Message
+		e.mu.Unlock()
+	case level == LogLevelError && message != "":
+		e.mu.Lock()
+		e.lastEngineError = message
+		e.mu.Unlock()
+	}
+
+	if e.handleLog(line) {
+		return
+	}
+
+	switch {
+	case panicErr != nil:
+		log.Println(panicErr.Error())
+		if panicErr.Backtrace != "" {
+			log.Println(panicErr.Backtrace)
+		}
+	case level == LogLevelError, level == LogLevelWarn:
+		log.Println(message)
+	default:
+		logger.Debug.Printf("query engine: %s", message)
+	}
+}
+
+// takePanic returns the panic the query engine printed and resets it, so a panic is only reported once.
+// e.mu must be locked.
+func (e *QueryEngine) takePanic() *EnginePanicError {
+	panicErr := e.panicErr
+	e.panicErr = nil
+	return panicErr
+}
+
+// classifyStderr returns the level and message of a line of query engine output, and whether it's a panic.
+// The query engine prints log events, error messages such as {"is_panic":false,"message":"..."}, and plain text.
+func classifyStderr(line []byte) (LogLevel, string, *EnginePanicError) {
+	if event, ok := parseLogEvent(line); ok {
+		message := event.Message
+		if event.Level == LogLevelQuery {
+			message = event.String()
+		}
+
+		// panics are logged as {"level":"ERROR","fields":{"message":"PANIC","reason":"...","file":"...","line":1,"column":1}}
+		if event.Level == LogLevelError && strings.HasPrefix(message, "PANIC") {
+			return event.Level, message, panicFromFields(event.Fields)
+		}
+
+		return event.Level, message, nil
+	}
+
+	var message Messsage
+	if err := json.Unmarshal(line, &message); err == nil && message.Message != "" {
+		if message.IsPanic {
+			return LogLevelError, message.Message, &EnginePanicError{
+				Message:   message.Message,
+				Backtrace: message.Backtrace,
+			}
+		}
+		return LogLevelError, message.Message, nil
+	}
+
+	// panics which happen before logging is set up are printed as plain text by the rust runtime, e.g.
+	// thread 'main' panicked at src/main.rs:1:1:
+	if text := string(line); strings.HasPrefix(text, "thread '") && strings.Contains(text, "' panicked at ") {
+		return LogLevelError, text, &EnginePanicError{Message: text}
+	}
+
+	return LogLevelInfo, string(line), nil
+}
+
+// panicFromFields builds a panic error from the fields of a panic log event
+func panicFromFields(fields map[string]interface{}) *EnginePanicError {
+	message, _ := fields["message"].(string)
+	if reason, ok := fields["reason"].(string); ok && reason != "" {
+		message = reason
+	}
+	if file, ok := fields["file"].(string); ok && file != "" {
+		message += fmt.Sprintf(" in %s:%v:%v", file, fields["line"], fields["column"])
+	}
+	backtrace, _ := fields["backtrace"].(string)
+	return &EnginePanicError{
+		Message:   message,
+		Backtrace: backtrace,
+	}
 }
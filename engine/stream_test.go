@@ -0,0 +1,128 @@
+package engine
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_classifyStderr(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		level   LogLevel
+		message string
+		panic   *EnginePanicError
+	}{{
+		name:    "info",
+		line:    `{"level":"INFO","fields":{"message":"Started query engine"},"target":"a"}`,
+		level:   LogLevelInfo,
+		message: "Started query engine",
+	}, {
+		name:    "error",
+		line:    `{"level":"ERROR","fields":{"message":"connection lost"},"target":"a"}`,
+		level:   LogLevelError,
+		message: "connection lost",
+	}, {
+		name:    "panic log event",
+		line:    `{"level":"ERROR","fields":{"message":"PANIC","reason":"index out of bounds","file":"src/lib.rs","line":10,"column":5,"backtrace":"0: main"},"target":"query_engine"}`,
+		level:   LogLevelError,
+		message: "PANIC",
+		panic: &EnginePanicError{
+			Message:   "index out of bounds in src/lib.rs:10:5",
+			Backtrace: "0: main",
+		},
+	}, {
+		name:    "error message",
+		line:    `{"is_panic":false,"message":"could not connect"}`,
+		level:   LogLevelError,
+		message: "could not connect",
+	}, {
+		name:    "panic message",
+		line:    `{"is_panic":true,"message":"boom","backtrace":"0: main"}`,
+		level:   LogLevelError,
+		message: "boom",
+		panic: &EnginePanicError{
+			Message:   "boom",
+			Backtrace: "0: main",
+		},
+	}, {
+		name:    "plain text panic",
+		line:    `thread 'main' panicked at src/main.rs:1:1:`,
+		level:   LogLevelError,
+		message: `thread 'main' panicked at src/main.rs:1:1:`,
+		panic:   &EnginePanicError{Message: `thread 'main' panicked at src/main.rs:1:1:`},
+	}, {
+		name:    "plain text",
+		line:    `hello`,
+		level:   LogLevelInfo,
+		message: `hello`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, message, panicErr := classifyStderr([]byte(tt.line))
+			assert.Equal(t, tt.level, level)
+			assert.Equal(t, tt.message, message)
+			assert.Equal(t, tt.panic, panicErr)
+		})
+	}
+}
+
+func TestQueryEngine_handleStderr(t *testing.T) {
+	e := NewQueryEngine("", false, "", "")
+
+	e.handleStderr([]byte(`{"level":"INFO","fields":{"message":"info"},"target":"a"}`))
+	assert.Equal(t, "", e.lastEngineError)
+
+	e.handleStderr([]byte(`{"is_panic":false,"message":"could not connect"}`))
+	assert.Equal(t, "could not connect", e.lastEngineError)
+	assert.Nil(t, e.panicErr)
+
+	e.handleStderr([]byte(`{"is_panic":true,"message":"boom","backtrace":"0: main"}`))
+	assert.Equal(t, "boom", e.lastEngineError)
+	assert.Equal(t, &EnginePanicError{Message: "boom", Backtrace: "0: main"}, e.panicErr)
+
+	// a panic is reported once
+	assert.Equal(t, &EnginePanicError{Message: "boom", Backtrace: "0: main"}, e.takePanic())
+	assert.Nil(t, e.takePanic())
+}
+
+func TestEngineCrashedError_panic(t *testing.T) {
+	var err error = &EngineCrashedError{
+		Message: "boom",
+		Panic:   &EnginePanicError{Message: "boom"},
+	}
+
+	var panicErr *EnginePanicError
+	assert.True(t, errors.As(err, &panicErr))
+	assert.Equal(t, "boom", panicErr.Message)
+}
+
+func TestQueryEngine_startPanic(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a shell")
+	}
+
+	// a query engine which panics on start, with an output line larger than the default scanner buffer
+	file := filepath.Join(t.TempDir(), "query-engine")
+	script := `#!/bin/sh
+head -c 100000 /dev/zero | tr '\0' 'a' >&2
+echo >&2
+echo '{"is_panic":true,"message":"boom on start","backtrace":"0: main"}' >&2
+exit 101
+`
+	if err := os.WriteFile(file, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewQueryEngine("", false, "[]", "")
+	err := e.start(file, TransportTCP)
+
+	var panicErr *EnginePanicError
+	assert.True(t, errors.As(err, &panicErr), "unexpected error %v", err)
+	assert.Equal(t, &EnginePanicError{Message: "boom on start", Backtrace: "0: main"}, panicErr)
+}
@@ -0,0 +1,316 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/steebchen/prisma-client-go/engine/protocol"
+	"github.com/steebchen/prisma-client-go/logger"
+)
+
+const (
+	// maxRestartAttempts is the number of times a crashed query engine is restarted before giving up
+	maxRestartAttempts = 5
+
+	// minRestartBackoff is the initial delay before restarting a crashed query engine
+	minRestartBackoff = 100 * time.Millisecond
+
+	// maxRestartBackoff is the maximum delay between restart attempts
+	maxRestartBackoff = 5 * time.Second
+
+	// crashGracePeriod is the time to wait for a process exit after a request failed without a response to detect
+	// a crash
+	crashGracePeriod = 500 * time.Millisecond
+)
+
+// EventType describes a query engine lifecycle event
+type EventType string
+
+const (
+	// EventConnected is emitted when the query engine was started successfully
+	EventConnected EventType = "connected"
+	// EventCrashed is emitted when the query engine exited unexpectedly
+	EventCrashed EventType = "crashed"
+	// EventRestarted is emitted when a crashed query engine was restarted successfully
+	EventRestarted EventType = "restarted"
+	// EventRestartFailed is emitted when a crashed query engine could not be restarted
+	EventRestartFailed EventType = "restart-failed"
+)
+
+// Event is a query engine lifecycle event
+type Event struct {
+	Type EventType
+	// Err contains the cause of crashed and restart-failed events
+	Err error
+}
+
+// EngineCrashedError is returned when the query engine process exited unexpectedly
+type EngineCrashedError struct {
+	// Message contains the last error message the query engine printed before exiting
+	Message string
+	// ExitErr contains the process exit error
+	ExitErr error
+	// Panic is set when the query engine exited because it panicked
+	Panic *EnginePanicError
+}
+
+func (e *EngineCrashedError) Error() string {
+	msg := "query engine crashed"
+	if e.ExitErr != nil {
+		msg += ": " + e.ExitErr.Error()
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+func (e *EngineCrashedError) Unwrap() []error {
+	var errs []error
+	if e.ExitErr != nil {
+		errs = append(errs, e.ExitErr)
+	}
+	if e.Panic != nil {
+		errs = append(errs, e.Panic)
+	}
+	return errs
+}
+
+// EnginePanicError is returned when the query engine panicked, e.g. because of a bug in the query engine.
+// Queries which panicked without crashing the query engine fail with it directly. Requests which were in-flight
+// or sent while a crashed query engine is restarted fail with an EngineCrashedError which wraps it.
+type EnginePanicError struct {
+	// Message contains the panic message
+	Message string
+	// Backtrace contains the backtrace of the panic, if the query engine printed it
+	Backtrace string
+}
+
+func (e *EnginePanicError) Error() string {
+	return "query engine panicked: " + e.Message
+}
+
+func (e *QueryEngine) emit(event Event) {
+	if e.onEvent != nil {
+		e.onEvent(event)
+	}
+}
+
+// watch waits for the query engine process to exit and restarts it if it crashed
+func (e *QueryEngine) watch(cmd *exec.Cmd, exited chan struct{}, socket string, stderrDone <-chan struct{}) {
+	// all output must be read before waiting, as the output may contain the reason of the exit, e.g. a panic
+	<-stderrDone
+	err := cmd.Wait()
+
+	removeSocket(socket)
+
+	e.mu.Lock()
+	e.exitErr = err
+	// only restart the current, healthy process after Connect() succeeded
+	supervised := e.connected && !e.disconnected && e.cmd == cmd && e.crashErr == nil
+	if supervised {
+		e.crashErr = &EngineCrashedError{
+			Message: e.lastEngineError,
+			ExitErr: err,
+			Panic:   e.takePanic(),
+		}
+		e.ready = make(chan struct{})
+	}
+	e.mu.Unlock()
+
+	close(exited)
+
+	if supervised {
+		e.restart()
+	}
+}
+
+// restart restarts a crashed query engine on a new port or socket with bounded backoff
+func (e *QueryEngine) restart() {
+	e.mu.RLock()
+	crashErr := e.crashErr
+	ready := e.ready
+	e.mu.RUnlock()
+
+	defer close(ready)
+
+	logger.Info.Printf("%s; restarting...", crashErr)
+	e.emit(Event{Type: EventCrashed, Err: crashErr})
+
+	backoff := minRestartBackoff
+	for attempt := 1; attempt <= maxRestartAttempts; attempt++ {
+		select {
+		case <-time.After(backoff):
+		case <-e.stop:
+			return
+		}
+		backoff = min(backoff*2, maxRestartBackoff)
+
+		e.mu.Lock()
+		if e.disconnected {
+			e.mu.Unlock()
+			return
+		}
+		e.lastEngineError = ""
+		e.panicErr = nil
+		e.mu.Unlock()
+
+		if err := e.spawn(e.file); err != nil {
+			logger.Info.Printf("query engine restart attempt %d failed: %s", attempt, err)
+			continue
+		}
+
+		e.mu.Lock()
+		// Disconnect() may have been called while spawning, after it read the process of the crashed engine
+		if e.disconnected {
+			cmd := e.cmd
+			exited := e.exited
+			e.mu.Unlock()
+			if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+				logger.Info.Printf("could not stop the restarted query engine: %s", err)
+			}
+			<-exited
+			return
+		}
+		e.crashErr = nil
+		e.mu.Unlock()
+
+		logger.Info.Printf("query engine restarted")
+		e.emit(Event{Type: EventRestarted})
+		return
+	}
+
+	logger.Info.Printf("giving up restarting the query engine after %d attempts", maxRestartAttempts)
+	e.emit(Event{Type: EventRestartFailed, Err: crashErr})
+}
+
+// supervisedRequest sends a request to the query engine and handles engine crashes.
+// Idempotent requests wait for a crashed engine to restart; other requests, and requests which were in-flight
+// when the engine crashed, fail with an EngineCrashedError. Retrying them is left to the retry middleware.
+func (e *QueryEngine) supervisedRequest(ctx context.Context, method string, path string, payload interface{}, idempotent bool) ([]byte, error) {
+	// requests in an interactive transaction can't wait for a restart, as the transaction is gone after a crash
+	if _, ok := TransactionID(ctx); ok {
+		idempotent = false
+	}
+
+	if err := e.waitReady(ctx, idempotent); err != nil {
+		return nil, err
+	}
+
+	e.mu.RLock()
+	exited := e.exited
+	e.mu.RUnlock()
+
+	body, err := e.Request(ctx, method, path, payload, true)
+	// externally managed query engines are not supervised
+	if err == nil || ctx.Err() != nil || exited == nil {
+		return body, err
+	}
+
+	// the engine responded, so it didn't crash
+	var te *transportError
+	if !errors.As(err, &te) {
+		return nil, err
+	}
+
+	// check whether the request failed because the engine process exited
+	select {
+	case <-exited:
+	case <-time.After(crashGracePeriod):
+		return nil, err
+	}
+
+	e.mu.RLock()
+	crashErr := e.crashErr
+	e.mu.RUnlock()
+
+	if crashErr == nil {
+		return nil, err
+	}
+
+	return nil, crashErr
+}
+
+// waitReady returns an EngineCrashedError if the engine is crashed. If wait is true, it waits for the
+// engine to be restarted first.
+func (e *QueryEngine) waitReady(ctx context.Context, wait bool) error {
+	e.mu.RLock()
+	crashErr := e.crashErr
+	ready := e.ready
+	e.mu.RUnlock()
+
+	if crashErr == nil {
+		return nil
+	}
+
+	if !wait {
+		return crashErr
+	}
+
+	select {
+	case <-ready:
+	case <-ctx.Done():
+		return fmt.Errorf("wait for query engine restart: %w", errors.Join(ctx.Err(), crashErr))
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.crashErr != nil {
+		return e.crashErr
+	}
+	return nil
+}
+
+// isReadOnly returns whether a payload only contains read queries, which can be safely retried
+func isReadOnly(payload interface{}) bool {
+	switch p := payload.(type) {
+	case protocol.GQLRequest:
+		return strings.HasPrefix(strings.TrimSpace(p.Query), "query")
+	case protocol.GQLBatchRequest:
+		for _, r := range p.Batch {
+			if !isReadOnly(r) {
+				return false
+			}
+		}
+		return len(p.Batch) > 0
+	case protocol.JSONRequest:
+		return readActions[p.Action]
+	case protocol.JSONBatchRequest:
+		for _, r := range p.Batch {
+			if !isReadOnly(r) {
+				return false
+			}
+		}
+		return len(p.Batch) > 0
+	}
+	return false
+}
+
+// isTransactional returns whether a payload is a batch which is run in a transaction
+func isTransactional(payload interface{}) bool {
+	switch p := payload.(type) {
+	case protocol.GQLBatchRequest:
+		return p.Transaction != nil
+	case protocol.JSONBatchRequest:
+		return p.Transaction != nil
+	}
+	return false
+}
+
+// readActions contains the JSON protocol actions which only read data
+var readActions = map[string]bool{
+	"findUnique":        true,
+	"findUniqueOrThrow": true,
+	"findFirst":         true,
+	"findFirstOrThrow":  true,
+	"findMany":          true,
+	"aggregate":         true,
+	"groupBy":           true,
+	"findRaw":           true,
+	"aggregateRaw":      true,
+}
@@ -0,0 +1,153 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/steebchen/prisma-client-go/engine/protocol"
+)
+
+func Test_isReadOnly(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload interface{}
+		want    bool
+	}{{
+		name:    "query",
+		payload: protocol.GQLRequest{Query: "query {result: findUniqueUser(where:{id:\"a\"}) {id}}"},
+		want:    true,
+	}, {
+		name:    "mutation",
+		payload: protocol.GQLRequest{Query: "mutation {result: createOneUser(data:{}) {id}}"},
+		want:    false,
+	}, {
+		name: "batch of queries",
+		payload: protocol.GQLBatchRequest{Batch: []protocol.GQLRequest{
+			{Query: "query {result: findUniqueUser(where:{id:\"a\"}) {id}}"},
+			{Query: "query {result: findUniqueUser(where:{id:\"b\"}) {id}}"},
+		}},
+		want: true,
+	}, {
+		name: "batch with mutation",
+		payload: protocol.GQLBatchRequest{Batch: []protocol.GQLRequest{
+			{Query: "query {result: findUniqueUser(where:{id:\"a\"}) {id}}"},
+			{Query: "mutation {result: createOneUser(data:{}) {id}}"},
+		}},
+		want: false,
+	}, {
+		name:    "json query",
+		payload: protocol.JSONRequest{ModelName: "User", Action: "findUnique"},
+		want:    true,
+	}, {
+		name:    "json mutation",
+		payload: protocol.JSONRequest{ModelName: "User", Action: "createOne"},
+		want:    false,
+	}, {
+		name: "json batch with mutation",
+		payload: protocol.JSONBatchRequest{Batch: []protocol.JSONRequest{
+			{ModelName: "User", Action: "findMany"},
+			{ModelName: "User", Action: "deleteMany"},
+		}},
+		want: false,
+	}, {
+		name:    "unknown payload",
+		payload: map[string]interface{}{},
+		want:    false,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isReadOnly(tt.payload))
+		})
+	}
+}
+
+func TestQueryEngine_disconnectWhileRestarting(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a shell")
+	}
+
+	// a query engine which never becomes ready
+	file := filepath.Join(t.TempDir(), "query-engine")
+	if err := os.WriteFile(file, []byte("#!/bin/sh\nexec sleep 30\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewQueryEngine("", false, "[]", "", WithTransport(TransportTCP))
+	e.file = file
+	e.connected = true
+	e.crashErr = &EngineCrashedError{}
+	e.ready = make(chan struct{})
+	go e.restart()
+
+	// wait until the new process was started
+	assert.Eventually(t, func() bool {
+		e.mu.RLock()
+		defer e.mu.RUnlock()
+		return e.cmd != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	done := make(chan error)
+	go func() {
+		done <- e.Disconnect()
+	}()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Disconnect() didn't stop restarting the query engine")
+	}
+
+	e.mu.RLock()
+	exited := e.exited
+	e.mu.RUnlock()
+
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the restarted query engine is still running")
+	}
+}
+
+func TestQueryEngine_supervisedRequest(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"error":"validation failed"}`))
+	}))
+	defer s.Close()
+
+	e := NewQueryEngine("", false, "[]", "")
+	e.connected = true
+	e.httpURL = s.URL
+	e.http = s.Client()
+	e.exited = make(chan struct{})
+
+	// errors of the engine are returned right away, as the engine is still running
+	start := time.Now()
+	_, err := e.supervisedRequest(context.Background(), "POST", "/", protocol.GQLRequest{Query: "query {}"}, true)
+	assert.ErrorContains(t, err, "http status code 500")
+	assert.Less(t, time.Since(start), crashGracePeriod)
+
+	// requests which fail without a response wait for the process to exit
+	s.Close()
+	crashErr := &EngineCrashedError{Message: "out of memory"}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		e.mu.Lock()
+		e.crashErr = crashErr
+		e.ready = make(chan struct{})
+		e.mu.Unlock()
+		close(e.exited)
+	}()
+
+	_, err = e.supervisedRequest(context.Background(), "POST", "/", protocol.GQLRequest{Query: "query {}"}, true)
+	assert.Equal(t, crashErr, err)
+}
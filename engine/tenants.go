@@ -0,0 +1,447 @@
+package engine
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/steebchen/prisma-client-go/engine/protocol"
+	"github.com/steebchen/prisma-client-go/logger"
+)
+
+// DefaultMaxTenantEngines is the default number of tenant engines which are kept running at the same time
+const DefaultMaxTenantEngines = 10
+
+// TenantResolver returns the datasource URL of a tenant
+type TenantResolver func(tenant string) (string, error)
+
+type tenantKey struct{}
+
+// WithTenant makes all requests sent with the returned context use the datasource of the given tenant
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// Tenant returns the tenant of ctx, if any
+func Tenant(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok
+}
+
+// NewTenantEngine returns an engine which sends requests to the engine of the tenant set with WithTenant, or to
+// the engine of defaultURL if no tenant is set. Engines are created lazily per datasource URL using newEngine, and
+// at most maxEngines are kept running; the least recently used engine is disconnected once its requests are done.
+func NewTenantEngine(defaultURL string, resolve TenantResolver, maxEngines int, newEngine func(url string) Engine) *TenantEngine {
+	if maxEngines <= 0 {
+		maxEngines = DefaultMaxTenantEngines
+	}
+	return &TenantEngine{
+		defaultURL: defaultURL,
+		resolve:    resolve,
+		maxEngines: maxEngines,
+		newEngine:  newEngine,
+		prototype:  newEngine(defaultURL),
+		entries:    make(map[string]*tenantEntry),
+		lru:        list.New(),
+		txs:        make(map[string]*tenantEntry),
+		evicted:    make(map[*tenantEntry]bool),
+	}
+}
+
+// TenantEngine manages one engine per tenant datasource
+type TenantEngine struct {
+	defaultURL string
+	resolve    TenantResolver
+	maxEngines int
+	newEngine  func(url string) Engine
+
+	// prototype is never connected, and is used to describe the engines, e.g. their protocol
+	prototype Engine
+
+	// file holds the query engine binary path, which is resolved once and shared by all query engines
+	file string
+	// version holds the version of the query engine binary at file
+	version string
+
+	// entries contains the live engines by datasource url
+	entries map[string]*tenantEntry
+	// lru contains the live engines, the most recently used one first
+	lru *list.List
+	// txs contains the engines of open interactive transactions by transaction id
+	txs map[string]*tenantEntry
+	// evicted contains the evicted engines which are still used by requests or transactions
+	evicted map[*tenantEntry]bool
+	// stopping tracks the background disconnects of evicted engines, which Disconnect waits for
+	stopping sync.WaitGroup
+
+	disconnected bool
+
+	mu sync.Mutex
+}
+
+type tenantEntry struct {
+	url    string
+	engine Engine
+	elem   *list.Element
+
+	// ready is closed once the engine is connected, and err is set if connecting failed
+	ready chan struct{}
+	err   error
+
+	// refs counts the in-flight requests and open transactions, which need to finish before the engine is
+	// disconnected after being evicted
+	refs int
+}
+
+// acquire returns the connected engine of the tenant of ctx. It needs to be released after use.
+func (e *TenantEngine) acquire(ctx context.Context) (*tenantEntry, error) {
+	// requests in an interactive transaction are sent to the engine of the transaction, even if it was evicted
+	if id, ok := TransactionID(ctx); ok {
+		e.mu.Lock()
+		entry, ok := e.txs[id]
+		if ok {
+			entry.refs++
+		}
+		e.mu.Unlock()
+		if ok {
+			return entry, nil
+		}
+	}
+
+	url := e.defaultURL
+	if tenant, ok := Tenant(ctx); ok {
+		var err error
+		url, err = e.resolve(tenant)
+		if err != nil {
+			return nil, fmt.Errorf("resolve tenant %q: %w", tenant, err)
+		}
+	}
+
+	e.mu.Lock()
+	if e.disconnected {
+		e.mu.Unlock()
+		return nil, fmt.Errorf("client is already disconnected")
+	}
+
+	entry, ok := e.entries[url]
+	if ok {
+		e.lru.MoveToFront(entry.elem)
+	} else {
+		entry = &tenantEntry{
+			url:    url,
+			engine: e.newEngine(url),
+			ready:  make(chan struct{}),
+		}
+		if qe, ok := entry.engine.(*QueryEngine); ok {
+			qe.file = e.file
+			qe.version = e.version
+		}
+		entry.elem = e.lru.PushFront(entry)
+		e.entries[url] = entry
+		e.evict()
+	}
+	entry.refs++
+	e.mu.Unlock()
+
+	if !ok {
+		e.connect(entry)
+	}
+
+	select {
+	case <-entry.ready:
+	case <-ctx.Done():
+		e.release(entry)
+		return nil, ctx.Err()
+	}
+
+	if entry.err != nil {
+		e.release(entry)
+		return nil, entry.err
+	}
+
+	return entry, nil
+}
+
+func (e *TenantEngine) connect(entry *tenantEntry) {
+	defer close(entry.ready)
+
+	logger.Debug.Printf("starting tenant engine")
+
+	if err := entry.engine.Connect(); err != nil {
+		entry.err = fmt.Errorf("connect tenant engine: %w", err)
+
+		e.mu.Lock()
+		if e.entries[entry.url] == entry {
+			delete(e.entries, entry.url)
+			e.lru.Remove(entry.elem)
+		}
+		e.mu.Unlock()
+		return
+	}
+
+	if qe, ok := entry.engine.(*QueryEngine); ok {
+		e.mu.Lock()
+		e.file = qe.file
+		e.version = qe.version
+		e.mu.Unlock()
+	}
+}
+
+// evict removes the least recently used engines until at most maxEngines are live
+func (e *TenantEngine) evict() {
+	for e.lru.Len() > e.maxEngines {
+		entry := e.lru.Remove(e.lru.Back()).(*tenantEntry)
+		delete(e.entries, entry.url)
+		if entry.refs == 0 {
+			e.stop(entry)
+		} else {
+			e.evicted[entry] = true
+		}
+	}
+}
+
+func (e *TenantEngine) release(entry *tenantEntry) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	entry.refs--
+	if e.evicted[entry] && entry.refs == 0 {
+		delete(e.evicted, entry)
+		e.stop(entry)
+	}
+}
+
+// stop disconnects an evicted engine in the background. e.mu must be held.
+func (e *TenantEngine) stop(entry *tenantEntry) {
+	e.stopping.Add(1)
+	go e.disconnect(entry)
+}
+
+func (e *TenantEngine) disconnect(entry *tenantEntry) {
+	defer e.stopping.Done()
+
+	<-entry.ready
+	if entry.err != nil {
+		return
+	}
+
+	logger.Debug.Printf("stopping evicted tenant engine")
+
+	if err := entry.engine.Disconnect(); err != nil {
+		logger.Info.Printf("could not disconnect tenant engine: %s", err)
+	}
+}
+
+// Connect does not start any engines, as they are started lazily for each tenant
+func (e *TenantEngine) Connect() error {
+	return nil
+}
+
+// Disconnect disconnects all live engines and waits until evicted engines are disconnected
+func (e *TenantEngine) Disconnect() error {
+	e.mu.Lock()
+	e.disconnected = true
+	var entries []*tenantEntry
+	for _, entry := range e.entries {
+		entries = append(entries, entry)
+	}
+	// evicted engines which are still in use are disconnected right away, like live engines
+	for entry := range e.evicted {
+		entries = append(entries, entry)
+	}
+	e.entries = make(map[string]*tenantEntry)
+	e.evicted = make(map[*tenantEntry]bool)
+	e.lru.Init()
+	e.mu.Unlock()
+
+	errs := make([]error, len(entries))
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		wg.Add(1)
+		go func(i int, entry *tenantEntry) {
+			defer wg.Done()
+			<-entry.ready
+			if entry.err == nil {
+				errs[i] = entry.engine.Disconnect()
+			}
+		}(i, entry)
+	}
+	wg.Wait()
+	e.stopping.Wait()
+
+	return errors.Join(errs...)
+}
+
+func (e *TenantEngine) Do(ctx context.Context, payload interface{}, into interface{}) error {
+	entry, err := e.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer e.release(entry)
+
+	return entry.engine.Do(ctx, payload, into)
+}
+
+func (e *TenantEngine) Batch(ctx context.Context, payload interface{}, into interface{}) error {
+	entry, err := e.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer e.release(entry)
+
+	return entry.engine.Batch(ctx, payload, into)
+}
+
+// StartTx starts an interactive transaction. The engine of the tenant is kept running until the transaction ends.
+func (e *TenantEngine) StartTx(ctx context.Context, options protocol.TransactionStartRequest) (string, error) {
+	entry, err := e.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	id, err := entry.engine.StartTx(ctx, options)
+	if err != nil {
+		e.release(entry)
+		return "", err
+	}
+
+	e.mu.Lock()
+	e.txs[id] = entry
+	e.mu.Unlock()
+
+	return id, nil
+}
+
+func (e *TenantEngine) CommitTx(ctx context.Context, id string) error {
+	entry, err := e.endTx(id)
+	if err != nil {
+		return err
+	}
+	defer e.release(entry)
+
+	return entry.engine.CommitTx(ctx, id)
+}
+
+func (e *TenantEngine) RollbackTx(ctx context.Context, id string) error {
+	entry, err := e.endTx(id)
+	if err != nil {
+		return err
+	}
+	defer e.release(entry)
+
+	return entry.engine.RollbackTx(ctx, id)
+}
+
+// endTx returns the engine of an interactive transaction, which needs to be released after the transaction ended
+func (e *TenantEngine) endTx(id string) (*tenantEntry, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	entry, ok := e.txs[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown transaction %s", id)
+	}
+	delete(e.txs, id)
+
+	return entry, nil
+}
+
+func (e *TenantEngine) Metrics(ctx context.Context, format MetricsFormat) ([]byte, error) {
+	entry, err := e.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer e.release(entry)
+
+	return entry.engine.Metrics(ctx, format)
+}
+
+// Ping checks the engine of the tenant of ctx, which is started if it isn't running yet
+func (e *TenantEngine) Ping(ctx context.Context) error {
+	entry, err := e.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer e.release(entry)
+
+	return entry.engine.Ping(ctx)
+}
+
+// Status returns whether the tenant engine was disconnected. The engines of the tenants are started lazily, so
+// there is no single process id or uptime.
+func (e *TenantEngine) Status() Status {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	status := Status{
+		State:   StateConnected,
+		Version: e.version,
+	}
+	if e.disconnected {
+		status.State = StateDisconnected
+	}
+	return status
+}
+
+func (e *TenantEngine) Name() string {
+	return e.prototype.Name()
+}
+
+func (e *TenantEngine) Protocol() Protocol {
+	return e.prototype.Protocol()
+}
+
+func (e *TenantEngine) TracerProvider() trace.TracerProvider {
+	return e.prototype.TracerProvider()
+}
+
+// ForTenant returns an engine which sends all requests to the engine of the given tenant.
+// Connecting or disconnecting it has no effect, as the engines are managed by the parent engine.
+func ForTenant(e Engine, tenant string) Engine {
+	return tenantEngine{Engine: e, tenant: tenant}
+}
+
+type tenantEngine struct {
+	Engine
+	tenant string
+}
+
+func (e tenantEngine) Connect() error {
+	return nil
+}
+
+func (e tenantEngine) Disconnect() error {
+	return nil
+}
+
+func (e tenantEngine) Do(ctx context.Context, payload interface{}, into interface{}) error {
+	return e.Engine.Do(WithTenant(ctx, e.tenant), payload, into)
+}
+
+func (e tenantEngine) Batch(ctx context.Context, payload interface{}, into interface{}) error {
+	return e.Engine.Batch(WithTenant(ctx, e.tenant), payload, into)
+}
+
+func (e tenantEngine) StartTx(ctx context.Context, options protocol.TransactionStartRequest) (string, error) {
+	return e.Engine.StartTx(WithTenant(ctx, e.tenant), options)
+}
+
+func (e tenantEngine) CommitTx(ctx context.Context, id string) error {
+	return e.Engine.CommitTx(WithTenant(ctx, e.tenant), id)
+}
+
+func (e tenantEngine) RollbackTx(ctx context.Context, id string) error {
+	return e.Engine.RollbackTx(WithTenant(ctx, e.tenant), id)
+}
+
+func (e tenantEngine) Metrics(ctx context.Context, format MetricsFormat) ([]byte, error) {
+	return e.Engine.Metrics(WithTenant(ctx, e.tenant), format)
+}
+
+func (e tenantEngine) Ping(ctx context.Context) error {
+	return e.Engine.Ping(WithTenant(ctx, e.tenant))
+}
@@ -0,0 +1,182 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/steebchen/prisma-client-go/engine/protocol"
+)
+
+// tenantLog records the calls of all engines of a tenant engine
+type tenantLog struct {
+	calls []string
+	mu    sync.Mutex
+}
+
+func (l *tenantLog) add(call string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, call)
+}
+
+func (l *tenantLog) get() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.calls...)
+}
+
+type fakeTenantEngine struct {
+	Engine
+	url string
+	log *tenantLog
+}
+
+func (e fakeTenantEngine) Connect() error {
+	e.log.add("connect " + e.url)
+	if e.url == "broken" {
+		return errors.New("connect failed")
+	}
+	return nil
+}
+
+func (e fakeTenantEngine) Disconnect() error {
+	if e.url == "slow" {
+		time.Sleep(50 * time.Millisecond)
+	}
+	e.log.add("disconnect " + e.url)
+	return nil
+}
+
+func (e fakeTenantEngine) Do(ctx context.Context, payload interface{}, into interface{}) error {
+	e.log.add("do " + e.url)
+	return nil
+}
+
+func (e fakeTenantEngine) StartTx(ctx context.Context, options protocol.TransactionStartRequest) (string, error) {
+	e.log.add("start " + e.url)
+	return "tx-" + e.url, nil
+}
+
+func (e fakeTenantEngine) CommitTx(ctx context.Context, id string) error {
+	e.log.add("commit " + e.url)
+	return nil
+}
+
+func newTestTenantEngine(maxEngines int) (*TenantEngine, *tenantLog) {
+	log := &tenantLog{}
+	e := NewTenantEngine("default", func(tenant string) (string, error) {
+		if tenant == "unknown" {
+			return "", errors.New("unknown tenant")
+		}
+		return tenant, nil
+	}, maxEngines, func(url string) Engine {
+		return fakeTenantEngine{url: url, log: log}
+	})
+	return e, log
+}
+
+// waitFor waits until the background disconnects of evicted engines are done
+func waitFor(t *testing.T, log *tenantLog, call string) {
+	for i := 0; i < 100; i++ {
+		for _, c := range log.get() {
+			if c == call {
+				return
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("%q was not called: %v", call, log.get())
+}
+
+func TestTenantEngine_Do(t *testing.T) {
+	e, log := newTestTenantEngine(2)
+	ctx := context.Background()
+
+	assert.NoError(t, e.Connect())
+	assert.NoError(t, e.Do(ctx, nil, nil))
+	assert.NoError(t, e.Do(WithTenant(ctx, "a"), nil, nil))
+	assert.NoError(t, e.Do(WithTenant(ctx, "a"), nil, nil))
+	assert.NoError(t, ForTenant(e, "b").Do(ctx, nil, nil))
+
+	// default was used least recently, so it is evicted
+	waitFor(t, log, "disconnect default")
+
+	assert.NoError(t, e.Do(WithTenant(ctx, "a"), nil, nil))
+
+	assert.Equal(t, []string{
+		"connect default",
+		"do default",
+		"connect a",
+		"do a",
+		"do a",
+		"connect b",
+		"do b",
+		"disconnect default",
+		"do a",
+	}, log.get())
+
+	assert.NoError(t, e.Disconnect())
+	assert.ElementsMatch(t, []string{"disconnect a", "disconnect b"}, log.get()[9:])
+
+	assert.Error(t, e.Do(ctx, nil, nil))
+}
+
+func TestTenantEngine_errors(t *testing.T) {
+	e, log := newTestTenantEngine(2)
+	ctx := context.Background()
+
+	assert.ErrorContains(t, e.Do(WithTenant(ctx, "unknown"), nil, nil), "unknown tenant")
+	assert.ErrorContains(t, e.Do(WithTenant(ctx, "broken"), nil, nil), "connect failed")
+
+	// engines which failed to connect are started again on the next request
+	assert.ErrorContains(t, e.Do(WithTenant(ctx, "broken"), nil, nil), "connect failed")
+
+	assert.Equal(t, []string{"connect broken", "connect broken"}, log.get())
+}
+
+func TestTenantEngine_transaction(t *testing.T) {
+	e, log := newTestTenantEngine(1)
+	ctx := WithTenant(context.Background(), "a")
+
+	id, err := e.StartTx(ctx, protocol.TransactionStartRequest{})
+	assert.NoError(t, err)
+
+	// evicts a, which is kept running until the transaction ends
+	assert.NoError(t, e.Do(WithTenant(ctx, "b"), nil, nil))
+
+	assert.NoError(t, e.Do(WithTransactionID(ctx, id), nil, nil))
+	assert.NoError(t, e.CommitTx(ctx, id))
+
+	waitFor(t, log, "disconnect a")
+
+	assert.Equal(t, []string{
+		"connect a",
+		"start a",
+		"connect b",
+		"do b",
+		"do a",
+		"commit a",
+		"disconnect a",
+	}, log.get())
+}
+
+func TestTenantEngine_Disconnect(t *testing.T) {
+	e, log := newTestTenantEngine(1)
+	ctx := context.Background()
+
+	_, err := e.StartTx(WithTenant(ctx, "a"), protocol.TransactionStartRequest{})
+	assert.NoError(t, err)
+
+	// evicts a, which is still used by the transaction, and then slow, which is disconnected in the background
+	assert.NoError(t, e.Do(WithTenant(ctx, "slow"), nil, nil))
+	assert.NoError(t, e.Do(WithTenant(ctx, "b"), nil, nil))
+
+	// all engines are disconnected once Disconnect returns, including evicted ones
+	assert.NoError(t, e.Disconnect())
+	assert.Subset(t, log.get(), []string{"disconnect a", "disconnect slow", "disconnect b"})
+}
@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/steebchen/prisma-client-go/engine/protocol"
+	"github.com/steebchen/prisma-client-go/logger"
+)
+
+// TracerName is the instrumentation name of all spans created by the client
+const TracerName = "github.com/steebchen/prisma-client-go"
+
+// WithTracerProvider enables OpenTelemetry tracing. The query engine is started with tracing enabled, and its
+// internal spans are emitted as child spans of the request.
+func WithTracerProvider(tp trace.TracerProvider) QueryEngineOption {
+	return func(e *QueryEngine) {
+		e.tracerProvider = tp
+	}
+}
+
+// TracerProvider returns the tracer provider, or a no-op tracer provider if tracing is disabled
+func (e *QueryEngine) TracerProvider() trace.TracerProvider {
+	if e.tracerProvider == nil {
+		return noop.NewTracerProvider()
+	}
+	return e.tracerProvider
+}
+
+// EndSpan records the error, if any, and ends the span
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// injectTraceParent propagates the span in ctx to the query engine via the traceparent header
+func injectTraceParent(ctx context.Context, req *http.Request) {
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(req.Header))
+}
+
+// tracedRequest sends a request to the query engine in a span, and emits the engine spans of the response as
+// children of that span
+func (e *QueryEngine) tracedRequest(ctx context.Context, payload interface{}) ([]byte, error) {
+	if e.tracerProvider == nil {
+		return e.supervisedRequest(ctx, "POST", "/", payload, isReadOnly(payload))
+	}
+
+	ctx, span := e.tracerProvider.Tracer(TracerName).Start(ctx, "prisma:client:request", trace.WithSpanKind(trace.SpanKindClient))
+	body, err := e.supervisedRequest(ctx, "POST", "/", payload, isReadOnly(payload))
+	if err == nil {
+		e.emitEngineSpans(ctx, body)
+	}
+	EndSpan(span, err)
+
+	return body, err
+}
+
+// emitEngineSpans re-emits the query engine spans, which are returned in the response extensions
+func (e *QueryEngine) emitEngineSpans(ctx context.Context, body []byte) {
+	var response struct {
+		Extensions struct {
+			Traces []protocol.EngineSpan `json:"traces"`
+		} `json:"extensions"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		logger.Debug.Printf("could not read engine spans: %s", err)
+		return
+	}
+
+	tracer := e.tracerProvider.Tracer(TracerName)
+
+	// engine spans reference their parent by id, so each parent needs to be started before its children
+	parents := map[string]context.Context{
+		trace.SpanContextFromContext(ctx).SpanID().String(): ctx,
+	}
+	remaining := response.Extensions.Traces
+	for len(remaining) > 0 {
+		var next []protocol.EngineSpan
+		for _, s := range remaining {
+			parent, ok := parents[s.ParentSpanID]
+			if !ok {
+				next = append(next, s)
+				continue
+			}
+			parents[s.SpanID] = emitEngineSpan(parent, tracer, s)
+		}
+
+		// spans with unknown parents are attached to the request span
+		if len(next) == len(remaining) {
+			for _, s := range next {
+				emitEngineSpan(ctx, tracer, s)
+			}
+			return
+		}
+		remaining = next
+	}
+}
+
+func emitEngineSpan(ctx context.Context, tracer trace.Tracer, s protocol.EngineSpan) context.Context {
+	attributes := make([]attribute.KeyValue, 0, len(s.Attributes))
+	for key, value := range s.Attributes {
+		switch v := value.(type) {
+		case string:
+			attributes = append(attributes, attribute.String(key, v))
+		case float64:
+			attributes = append(attributes, attribute.Float64(key, v))
+		case bool:
+			attributes = append(attributes, attribute.Bool(key, v))
+		default:
+			attributes = append(attributes, attribute.String(key, fmt.Sprintf("%v", v)))
+		}
+	}
+
+	ctx, span := tracer.Start(ctx, s.Name,
+		trace.WithTimestamp(s.StartTime.Time()),
+		trace.WithAttributes(attributes...),
+	)
+	span.End(trace.WithTimestamp(s.EndTime.Time()))
+
+	return ctx
+}
@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestQueryEngine_emitEngineSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	e := NewQueryEngine("", false, "", "", WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))))
+
+	ctx, span := e.TracerProvider().Tracer(TracerName).Start(context.Background(), "request")
+	parentID := span.SpanContext().SpanID().String()
+
+	// the child span is listed before its parent
+	body := `{"data":{},"extensions":{"traces":[
+		{"name":"prisma:engine:db_query","span_id":"2","parent_span_id":"1","start_time":[1,500],"end_time":[2,0],"attributes":{"db.statement":"SELECT 1"}},
+		{"name":"prisma:engine","span_id":"1","parent_span_id":"` + parentID + `","start_time":[1,0],"end_time":[3,0]},
+		{"name":"prisma:engine:unknown_parent","span_id":"3","parent_span_id":"4","start_time":[1,0],"end_time":[3,0]}
+	]}}`
+	e.emitEngineSpans(ctx, []byte(body))
+	span.End()
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+	}
+
+	assert.Len(t, spans, 4)
+	assert.Equal(t, span.SpanContext().SpanID(), spans["prisma:engine"].Parent().SpanID())
+	assert.Equal(t, spans["prisma:engine"].SpanContext().SpanID(), spans["prisma:engine:db_query"].Parent().SpanID())
+	assert.Equal(t, span.SpanContext().SpanID(), spans["prisma:engine:unknown_parent"].Parent().SpanID())
+
+	dbQuery := spans["prisma:engine:db_query"]
+	assert.Equal(t, time.Unix(1, 500), dbQuery.StartTime())
+	assert.Equal(t, time.Unix(2, 0), dbQuery.EndTime())
+	assert.Equal(t, "SELECT 1", dbQuery.Attributes()[0].Value.AsString())
+}
+
+func Test_injectTraceParent(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+	ctx, span := tp.Tracer(TracerName).Start(context.Background(), "request")
+	defer span.End()
+
+	req, err := http.NewRequest("POST", "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	injectTraceParent(ctx, req)
+
+	want := "00-" + span.SpanContext().TraceID().String() + "-" + span.SpanContext().SpanID().String() + "-01"
+	assert.Equal(t, want, req.Header.Get("traceparent"))
+}
@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/steebchen/prisma-client-go/engine/protocol"
+)
+
+type transactionIDKey struct{}
+
+// WithTransactionID returns a context which makes the engine send requests as part of the
+// given interactive transaction
+func WithTransactionID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, transactionIDKey{}, id)
+}
+
+// TransactionID returns the interactive transaction id of the given context, if set
+func TransactionID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(transactionIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// StartTx starts an interactive transaction and returns its id
+func (e *QueryEngine) StartTx(ctx context.Context, options protocol.TransactionStartRequest) (string, error) {
+	body, err := e.supervisedRequest(ctx, "POST", "/transaction/start", options, false)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+
+	var response protocol.TransactionStartResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("json transaction response unmarshal: %w", err)
+	}
+
+	if response.ID == "" {
+		return "", fmt.Errorf("no transaction id returned: %s", body)
+	}
+
+	return response.ID, nil
+}
+
+// CommitTx commits the interactive transaction with the given id
+func (e *QueryEngine) CommitTx(ctx context.Context, id string) error {
+	if _, err := e.supervisedRequest(ctx, "POST", "/transaction/"+id+"/commit", map[string]interface{}{}, false); err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	return nil
+}
+
+// RollbackTx rolls back the interactive transaction with the given id
+func (e *QueryEngine) RollbackTx(ctx context.Context, id string) error {
+	if _, err := e.supervisedRequest(ctx, "POST", "/transaction/"+id+"/rollback", map[string]interface{}{}, false); err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	return nil
+}
@@ -15,6 +15,7 @@ var Enabled = v != ""
 
 var Debug *log.Logger
 var Info *log.Logger
+var Warn *log.Logger
 
 func init() {
 	discard := log.New(io.Discard, "", 0)
@@ -25,4 +26,5 @@ func init() {
 	}
 
 	Info = log.New(os.Stdout, "[prisma-client-go] INFO: ", flag)
+	Warn = log.New(os.Stdout, "[prisma-client-go] WARN: ", flag)
 }
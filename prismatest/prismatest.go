@@ -0,0 +1,142 @@
+// Package prismatest provides isolated databases for tests of generated clients.
+//
+// Example:
+//
+//	func TestUsers(t *testing.T) {
+//	  t.Parallel()
+//	  client := prismatest.New(t, db.NewClient, prismatest.SQLite())
+//
+//	  user, err := client.User.CreateOne(db.User.Email.Set("a@example.com")).Exec(context.Background())
+//	  // ...
+//	}
+package prismatest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/steebchen/prisma-client-go/cli"
+)
+
+// Config is implemented by the PrismaConfig of generated clients
+type Config interface {
+	// Schema returns the Prisma schema and the name of the env var of its datasource URL
+	Schema() (prismaSchema string, urlEnvVar string)
+	// SetDatasourceURL sets the URL which the client connects to
+	SetDatasourceURL(url string)
+}
+
+// Client is implemented by generated clients
+type Client interface {
+	Connect() error
+	Disconnect() error
+}
+
+// Schema is the Prisma schema which a database is created for
+type Schema struct {
+	// Prisma is the content of the Prisma schema
+	Prisma string
+	// URLEnvVar is the name of the env var of the datasource URL in the schema
+	URLEnvVar string
+}
+
+// Database creates an isolated database for a test
+type Database interface {
+	// Create creates a database for the given schema and returns its URL. The database is removed with t.Cleanup.
+	Create(t testing.TB, schema Schema) string
+}
+
+// New returns a connected client with a fresh database for the test, which has the schema of the client. The client
+// is disconnected and the database is removed when the test finishes. Additional options are passed to newClient.
+//
+// Example:
+//
+//	client := prismatest.New(t, db.NewClient, prismatest.SQLite(), db.WithLogHandler(...))
+func New[C Client, O ~func(*P), P any, PC interface {
+	*P
+	Config
+}](t testing.TB, newClient func(options ...O) C, database Database, options ...O) C {
+	t.Helper()
+
+	var config P
+	prismaSchema, urlEnvVar := PC(&config).Schema()
+	url := database.Create(t, Schema{
+		Prisma:    prismaSchema,
+		URLEnvVar: urlEnvVar,
+	})
+
+	options = append(options, func(config *P) {
+		PC(config).SetDatasourceURL(url)
+	})
+	client := newClient(options...)
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("prismatest: connect: %s", err)
+	}
+
+	t.Cleanup(func() {
+		if err := client.Disconnect(); err != nil {
+			t.Errorf("prismatest: disconnect: %s", err)
+		}
+	})
+
+	return client
+}
+
+// Push creates the tables of the schema in the database at the given URL with `prisma db push`
+func Push(schema Schema, url string) error {
+	if schema.URLEnvVar == "" {
+		return fmt.Errorf("the datasource url needs to be set with env() in the schema")
+	}
+
+	dir, err := os.MkdirTemp("", "prismatest")
+	if err != nil {
+		return fmt.Errorf("create schema directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "schema.prisma")
+	if err := os.WriteFile(path, []byte(schema.Prisma), 0644); err != nil {
+		return fmt.Errorf("write schema: %w", err)
+	}
+
+	args := []string{"db", "push", "--schema=" + path, "--skip-generate"}
+	if err := cli.RunWithEnv(args, []string{schema.URLEnvVar + "=" + url}, testing.Verbose()); err != nil {
+		return fmt.Errorf("db push: %w", err)
+	}
+
+	return nil
+}
+
+// execute runs a SQL statement in the database at the given URL with `prisma db execute`
+func execute(url string, sql string) error {
+	dir, err := os.MkdirTemp("", "prismatest")
+	if err != nil {
+		return fmt.Errorf("create script directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "script.sql")
+	if err := os.WriteFile(path, []byte(sql), 0644); err != nil {
+		return fmt.Errorf("write script: %w", err)
+	}
+
+	if err := cli.Run([]string{"db", "execute", "--url=" + url, "--file=" + path}, testing.Verbose()); err != nil {
+		return fmt.Errorf("db execute: %w", err)
+	}
+
+	return nil
+}
+
+// randomName returns a random name for a database or schema
+func randomName() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return "prismatest_" + hex.EncodeToString(b)
+}
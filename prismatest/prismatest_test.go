@@ -0,0 +1,100 @@
+package prismatest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConfig and fakeClient look like the PrismaConfig and PrismaClient of generated clients
+type fakeConfig struct {
+	datasourceURL string
+}
+
+func (c *fakeConfig) Schema() (string, string) {
+	return "model User {}", "DATABASE_URL"
+}
+
+func (c *fakeConfig) SetDatasourceURL(url string) {
+	c.datasourceURL = url
+}
+
+func withDatasourceURL(url string) func(*fakeConfig) {
+	return func(c *fakeConfig) {
+		c.datasourceURL = url
+	}
+}
+
+type fakeClient struct {
+	config fakeConfig
+	calls  []string
+}
+
+func (c *fakeClient) Connect() error {
+	c.calls = append(c.calls, "connect")
+	return nil
+}
+
+func (c *fakeClient) Disconnect() error {
+	c.calls = append(c.calls, "disconnect")
+	return nil
+}
+
+func newFakeClient(options ...func(*fakeConfig)) *fakeClient {
+	var config fakeConfig
+	for _, option := range options {
+		option(&config)
+	}
+	return &fakeClient{config: config}
+}
+
+type fakeDatabase struct {
+	schema Schema
+}
+
+func (d *fakeDatabase) Create(t testing.TB, schema Schema) string {
+	d.schema = schema
+	return "file:" + t.Name() + ".db"
+}
+
+func TestNew(t *testing.T) {
+	database := &fakeDatabase{}
+	var client *fakeClient
+
+	t.Run("test", func(t *testing.T) {
+		// the URL of the test database takes precedence
+		client = New(t, newFakeClient, database, withDatasourceURL("file:dev.db"))
+		assert.Equal(t, []string{"connect"}, client.calls)
+	})
+
+	assert.Equal(t, Schema{Prisma: "model User {}", URLEnvVar: "DATABASE_URL"}, database.schema)
+	assert.Equal(t, "file:TestNew/test.db", client.config.datasourceURL)
+	assert.Equal(t, []string{"connect", "disconnect"}, client.calls)
+}
+
+func TestPushWithoutEnvVar(t *testing.T) {
+	err := Push(Schema{Prisma: "model User {}"}, "file:test.db")
+	assert.EqualError(t, err, "the datasource url needs to be set with env() in the schema")
+}
+
+func TestWithQuery(t *testing.T) {
+	url, err := withQuery("postgresql://postgres:pw@localhost:5432/test?schema=public&sslmode=disable", "schema", "prismatest_a")
+	assert.NoError(t, err)
+	assert.Equal(t, "postgresql://postgres:pw@localhost:5432/test?schema=prismatest_a&sslmode=disable", url)
+}
+
+func TestWithPath(t *testing.T) {
+	url, err := withPath("mysql://root:pw@localhost:3306/test?connection_limit=5", "prismatest_a")
+	assert.NoError(t, err)
+	assert.Equal(t, "mysql://root:pw@localhost:3306/prismatest_a?connection_limit=5", url)
+}
+
+func TestTemplateKey(t *testing.T) {
+	key := templateKey("model User {}", "6.2.1", "a")
+
+	assert.Equal(t, key, templateKey("model User {}", "6.2.1", "a"))
+	// templates pushed by other versions are not reused
+	assert.NotEqual(t, key, templateKey("model User {}", "6.2.1", "b"))
+	assert.NotEqual(t, key, templateKey("model User {}", "6.3.0", "a"))
+	assert.NotEqual(t, key, templateKey("model Post {}", "6.2.1", "a"))
+}
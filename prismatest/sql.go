@@ -0,0 +1,94 @@
+package prismatest
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+)
+
+// PostgreSQL creates a schema for each test in the PostgreSQL database at the given URL, e.g.
+// postgresql://postgres:pw@localhost:5432/test. The schema is dropped when the test finishes.
+func PostgreSQL(url string) Database {
+	return &postgresDatabase{url: url}
+}
+
+type postgresDatabase struct {
+	url string
+}
+
+func (d *postgresDatabase) Create(t testing.TB, schema Schema) string {
+	t.Helper()
+
+	name := randomName()
+	testURL, err := withQuery(d.url, "schema", name)
+	if err != nil {
+		t.Fatalf("prismatest: %s", err)
+	}
+
+	create(t, schema, testURL, fmt.Sprintf(`DROP SCHEMA IF EXISTS "%s" CASCADE`, name))
+
+	return testURL
+}
+
+// MySQL creates a database for each test on the MySQL server at the given URL, e.g.
+// mysql://root:pw@localhost:3306/test. The database is dropped when the test finishes.
+func MySQL(url string) Database {
+	return &mysqlDatabase{url: url}
+}
+
+type mysqlDatabase struct {
+	url string
+}
+
+func (d *mysqlDatabase) Create(t testing.TB, schema Schema) string {
+	t.Helper()
+
+	name := randomName()
+	testURL, err := withPath(d.url, name)
+	if err != nil {
+		t.Fatalf("prismatest: %s", err)
+	}
+
+	create(t, schema, testURL, fmt.Sprintf("DROP DATABASE IF EXISTS `%s`", name))
+
+	return testURL
+}
+
+// create pushes the schema to a new database or schema, which is removed with drop when the test finishes
+func create(t testing.TB, schema Schema, url string, drop string) {
+	t.Helper()
+
+	// db push creates the database or schema, so it may need to be removed even if pushing fails
+	t.Cleanup(func() {
+		if err := execute(url, drop); err != nil {
+			t.Errorf("prismatest: remove database: %s", err)
+		}
+	})
+
+	if err := Push(schema, url); err != nil {
+		t.Fatalf("prismatest: %s", err)
+	}
+}
+
+// withQuery sets a query parameter of a URL
+func withQuery(rawURL string, key, value string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("parse url: %w", err)
+	}
+	query := u.Query()
+	query.Set(key, value)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// withPath sets the database name of a URL
+func withPath(rawURL string, database string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("parse url: %w", err)
+	}
+	u.Path = "/" + database
+	u.RawPath = ""
+	return u.String(), nil
+}
@@ -0,0 +1,119 @@
+package prismatest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/steebchen/prisma-client-go/binaries"
+)
+
+// SQLite creates a SQLite database file for each test. The schema is pushed once to a template database in the temp
+// directory, which is copied for each test, and reused as long as the schema and the Prisma version don't change.
+func SQLite() Database {
+	return &sqliteDatabase{}
+}
+
+type sqliteDatabase struct{}
+
+// templates contains the template databases of all schemas, so each schema is pushed only once per process
+var templates = struct {
+	paths map[string]string
+	mu    sync.Mutex
+}{
+	paths: make(map[string]string),
+}
+
+func (d *sqliteDatabase) Create(t testing.TB, schema Schema) string {
+	t.Helper()
+
+	template, err := sqliteTemplate(schema)
+	if err != nil {
+		t.Fatalf("prismatest: create sqlite template database: %s", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "test.db")
+	if err := copyFile(template, path); err != nil {
+		t.Fatalf("prismatest: copy sqlite template database: %s", err)
+	}
+
+	return "file:" + path
+}
+
+// sqliteTemplate returns the path of the template database of a schema, and pushes the schema if needed
+func sqliteTemplate(schema Schema) (string, error) {
+	templates.mu.Lock()
+	defer templates.mu.Unlock()
+
+	key := templateKey(schema.Prisma, binaries.PrismaVersion, binaries.EngineVersion)
+
+	if path, ok := templates.paths[key]; ok {
+		return path, nil
+	}
+
+	dir := filepath.Join(os.TempDir(), "prismatest", key)
+	path := filepath.Join(dir, "template.db")
+
+	// the template may have been created by another test binary
+	if _, err := os.Stat(path); err == nil {
+		templates.paths[key] = path
+		return path, nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("create template directory: %w", err)
+	}
+
+	// push to a temporary file first, so other test binaries never see an incomplete template
+	tmp, err := os.CreateTemp(dir, "template-*.db")
+	if err != nil {
+		return "", fmt.Errorf("create template: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("create template: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := Push(schema, "file:"+tmp.Name()); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("move template: %w", err)
+	}
+
+	templates.paths[key] = path
+	return path, nil
+}
+
+// templateKey identifies the template database of a schema. It includes the CLI and query engine versions, as they
+// determine the database which is pushed.
+func templateKey(schema, cliVersion, engineVersion string) string {
+	hash := sha256.Sum256([]byte(cliVersion + "\n" + engineVersion + "\n" + schema))
+	return hex.EncodeToString(hash[:])
+}
+
+func copyFile(from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+
+	return dst.Close()
+}
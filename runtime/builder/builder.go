@@ -7,6 +7,9 @@ import (
 	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/steebchen/prisma-client-go/engine"
 	"github.com/steebchen/prisma-client-go/engine/protocol"
 	"github.com/steebchen/prisma-client-go/logger"
@@ -91,7 +94,16 @@ type Query struct {
 	// Start time of the request for tracing
 	Start time.Time
 
-	TxResult chan []byte
+	// TxResult receives the result of the query when it's sent as part of a transaction or batch
+	TxResult chan TxResponse
+}
+
+// TxResponse contains the result of a single query of a transaction or batch
+type TxResponse struct {
+	Data []byte
+
+	// Err is set when the query failed in a non-transactional batch
+	Err error
 }
 
 func (q Query) Build() (string, error) {
@@ -312,16 +324,51 @@ func checkFields(parent Field, fields []Field) error {
 	return nil
 }
 
-func (q Query) Exec(ctx context.Context, into interface{}) error {
+func (q Query) Exec(ctx context.Context, into interface{}) (err error) {
+	if q.Engine != nil {
+		var span trace.Span
+		ctx, span = q.Engine.TracerProvider().Tracer(engine.TracerName).Start(ctx, "prisma:client:operation",
+			trace.WithAttributes(
+				attribute.String("prisma.model", q.Model),
+				attribute.String("prisma.method", q.Method),
+				attribute.String("prisma.operation", q.Operation),
+			),
+		)
+		defer func() {
+			engine.EndSpan(span, err)
+		}()
+	}
+
+	handler := Chain(q.Engine, func(ctx context.Context, req *Request) error {
+		payload, err := req.Query.Payload()
+		if err != nil {
+			return err
+		}
+		return req.Query.Do(ctx, payload, req.Result)
+	})
+
+	return handler(ctx, &Request{
+		Model:     q.Model,
+		Method:    q.Method,
+		Operation: q.Operation,
+		Query:     q,
+		Result:    into,
+	})
+}
+
+// Payload builds the request payload in the protocol of the query engine
+func (q Query) Payload() (interface{}, error) {
+	if q.Engine != nil && q.Engine.Protocol() == engine.ProtocolJSON {
+		return q.BuildJSON(), nil
+	}
 	str, err := q.Build()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	payload := protocol.GQLRequest{
+	return protocol.GQLRequest{
 		Query:     str,
 		Variables: map[string]interface{}{},
-	}
-	return q.Do(ctx, payload, into)
+	}, nil
 }
 
 func (q Query) Do(ctx context.Context, payload interface{}, into interface{}) error {
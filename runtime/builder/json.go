@@ -0,0 +1,236 @@
+package builder
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/steebchen/prisma-client-go/engine/protocol"
+	"github.com/steebchen/prisma-client-go/runtime/types"
+)
+
+// BuildJSON builds the query as a JSON protocol request, where inputs are mapped to arguments and outputs to the
+// selection. In contrast to Build, duplicate fields never fail: they are merged where possible, and otherwise
+// combined with an AND filter.
+func (q Query) BuildJSON() protocol.JSONRequest {
+	return protocol.JSONRequest{
+		ModelName: q.Model,
+		Action:    q.Method,
+		Query:     jsonQuery(q.Inputs, q.Outputs),
+	}
+}
+
+func jsonQuery(inputs []Input, outputs []Output) protocol.JSONQuery {
+	query := protocol.JSONQuery{
+		Selection: jsonSelection(outputs),
+	}
+	if len(inputs) > 0 {
+		query.Arguments = jsonArguments(inputs)
+	}
+	return query
+}
+
+func jsonArguments(inputs []Input) map[string]interface{} {
+	arguments := make(map[string]interface{})
+	for _, i := range inputs {
+		if i.Value != nil {
+			setJSONField(arguments, Field{Name: i.Name, Value: i.Value}, jsonValue(i.Value))
+			continue
+		}
+
+		f := Field{
+			Name:   i.Name,
+			List:   i.WrapList,
+			Fields: i.Fields,
+		}
+		setJSONField(arguments, f, jsonField(f))
+	}
+	return arguments
+}
+
+func jsonSelection(outputs []Output) map[string]interface{} {
+	selection := make(map[string]interface{})
+
+	// queries without outputs such as raw queries return all scalars
+	if len(outputs) == 0 {
+		selection["$scalars"] = true
+		return selection
+	}
+
+	for _, o := range outputs {
+		if len(o.Inputs) == 0 && len(o.Outputs) == 0 {
+			selection[o.Name] = true
+			continue
+		}
+		selection[o.Name] = jsonQuery(o.Inputs, o.Outputs)
+	}
+
+	return selection
+}
+
+// jsonField returns the JSON value of a field, which is either a list or an object of sub-fields, or a value
+func jsonField(f Field) interface{} {
+	switch {
+	case f.Fields != nil && f.List:
+		return jsonList(f.Fields)
+	case f.Fields != nil:
+		return jsonObject(f.Fields)
+	case f.Value != nil && f.List:
+		return []interface{}{jsonValue(f.Value)}
+	case f.Value != nil:
+		return jsonValue(f.Value)
+	case f.List:
+		return []interface{}{}
+	}
+	return nil
+}
+
+func jsonObject(fields []Field) map[string]interface{} {
+	object := make(map[string]interface{})
+	for _, f := range fields {
+		if f.Name == "" {
+			continue
+		}
+		setJSONField(object, f, jsonField(f))
+	}
+	return object
+}
+
+func jsonList(fields []Field) []interface{} {
+	items := make([]interface{}, 0, len(fields))
+
+	// remember the index of named items, so that nested operations on the same field can be shared
+	// this is necessary for json filters and more
+	named := make(map[string]int)
+
+	for _, f := range fields {
+		value := jsonField(f)
+
+		if f.Name == "" {
+			items = append(items, value)
+			continue
+		}
+
+		if i, ok := named[f.Name]; ok && f.Fields != nil && !isOperator(f.Name) {
+			existing := items[i].(map[string]interface{})
+			if merged, ok := mergeJSONObjects(existing[f.Name], value); ok {
+				existing[f.Name] = merged
+				continue
+			}
+		}
+
+		named[f.Name] = len(items)
+		items = append(items, map[string]interface{}{f.Name: value})
+	}
+
+	return items
+}
+
+// setJSONField adds a field to an object. If the object already contains the field, lists are joined and objects are
+// merged; if the values conflict, the field is added as an additional AND filter.
+func setJSONField(object map[string]interface{}, f Field, value interface{}) {
+	existing, ok := object[f.Name]
+	if !ok {
+		object[f.Name] = value
+		return
+	}
+
+	switch {
+	// joining OR lists would change their meaning
+	case f.List && f.Name != "OR":
+		if list, ok := existing.([]interface{}); ok {
+			object[f.Name] = append(list, value.([]interface{})...)
+			return
+		}
+	case f.Fields != nil && !isOperator(f.Name):
+		if merged, ok := mergeJSONObjects(existing, value); ok {
+			object[f.Name] = merged
+			return
+		}
+	}
+
+	and, _ := object["AND"].([]interface{})
+	if and == nil && object["AND"] != nil {
+		and = []interface{}{object["AND"]}
+	}
+	object["AND"] = append(and, map[string]interface{}{f.Name: value})
+}
+
+// mergeJSONObjects merges two objects recursively, and returns false if they contain conflicting values
+func mergeJSONObjects(a, b interface{}) (interface{}, bool) {
+	objectA, okA := a.(map[string]interface{})
+	objectB, okB := b.(map[string]interface{})
+	if !okA || !okB {
+		return nil, false
+	}
+
+	merged := make(map[string]interface{}, len(objectA)+len(objectB))
+	for key, value := range objectA {
+		merged[key] = value
+	}
+	for key, value := range objectB {
+		existing, ok := merged[key]
+		if !ok {
+			merged[key] = value
+			continue
+		}
+		inner, ok := mergeJSONObjects(existing, value)
+		if !ok {
+			return nil, false
+		}
+		merged[key] = inner
+	}
+
+	return merged, true
+}
+
+func isOperator(name string) bool {
+	return name == "AND" || name == "OR" || name == "NOT"
+}
+
+// jsonValue converts a value to its JSON protocol representation, where special scalars are tagged with their type
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case time.Time:
+		return protocol.TypedValue{Type: "DateTime", Value: v.Format(time.RFC3339Nano)}
+	case decimal.Decimal:
+		return protocol.TypedValue{Type: "Decimal", Value: v.String()}
+	case types.BigInt:
+		return protocol.TypedValue{Type: "BigInt", Value: strconv.FormatInt(int64(v), 10)}
+	case types.JSON:
+		if v == nil {
+			return nil
+		}
+		return protocol.TypedValue{Type: "Json", Value: string(v)}
+	case []byte:
+		if v == nil {
+			return nil
+		}
+		return protocol.TypedValue{Type: "Bytes", Value: base64.StdEncoding.EncodeToString(v)}
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return nil
+		}
+		return jsonValue(rv.Elem().Interface())
+	case reflect.Slice:
+		if rv.IsNil() {
+			return nil
+		}
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			list[i] = jsonValue(rv.Index(i).Interface())
+		}
+		return list
+	}
+
+	return value
+}
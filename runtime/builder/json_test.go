@@ -0,0 +1,169 @@
+package builder
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/steebchen/prisma-client-go/runtime/types"
+)
+
+func TestQuery_BuildJSON(t *testing.T) {
+	date := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		query Query
+		want  string
+	}{{
+		name: "find unique with relation",
+		query: Query{
+			Method: "findUnique",
+			Model:  "User",
+			Inputs: []Input{{
+				Name: "where",
+				Fields: []Field{{
+					Name:  "id",
+					Value: "a",
+				}},
+			}},
+			Outputs: []Output{{
+				Name: "id",
+			}, {
+				Name: "posts",
+				Inputs: []Input{{
+					Name:  "take",
+					Value: 5,
+				}},
+				Outputs: []Output{{Name: "title"}},
+			}},
+		},
+		want: `{"modelName":"User","action":"findUnique","query":{"arguments":{"where":{"id":"a"}},"selection":{"id":true,"posts":{"arguments":{"take":5},"selection":{"title":true}}}}}`,
+	}, {
+		name: "typed values",
+		query: Query{
+			Method: "createOne",
+			Model:  "User",
+			Inputs: []Input{{
+				Name: "data",
+				Fields: []Field{
+					{Name: "date", Value: date},
+					{Name: "decimal", Value: decimal.NewFromFloat(1.5)},
+					{Name: "bigint", Value: types.BigInt(1)},
+					{Name: "json", Value: types.JSON(`{"a":1}`)},
+					{Name: "bytes", Value: []byte("abc")},
+					{Name: "optional", Value: (*string)(nil)},
+					{Name: "dates", Fields: []Field{{Name: "set", Value: []time.Time{date}}}},
+				},
+			}},
+			Outputs: []Output{{Name: "id"}},
+		},
+		want: `{"modelName":"User","action":"createOne","query":{"arguments":{"data":{"bigint":{"$type":"BigInt","value":"1"},"bytes":{"$type":"Bytes","value":"YWJj"},"date":{"$type":"DateTime","value":"2020-01-01T00:00:00Z"},"dates":{"set":[{"$type":"DateTime","value":"2020-01-01T00:00:00Z"}]},"decimal":{"$type":"Decimal","value":"1.5"},"json":{"$type":"Json","value":"{\"a\":1}"},"optional":null}},"selection":{"id":true}}}`,
+	}, {
+		name: "order by list",
+		query: Query{
+			Method: "findMany",
+			Model:  "User",
+			Inputs: []Input{{
+				Name:     "orderBy",
+				WrapList: true,
+				Fields: []Field{
+					{Name: "name", Value: "asc"},
+					{Name: "email", Value: "desc"},
+				},
+			}},
+			Outputs: []Output{{Name: "id"}},
+		},
+		want: `{"modelName":"User","action":"findMany","query":{"arguments":{"orderBy":[{"name":"asc"},{"email":"desc"}]},"selection":{"id":true}}}`,
+	}, {
+		name: "merge operations on the same field",
+		query: Query{
+			Method: "findMany",
+			Model:  "User",
+			Inputs: []Input{{
+				Name: "where",
+				Fields: []Field{
+					{Name: "meta", Fields: []Field{{Name: "path", Value: []string{"a"}}}},
+					{Name: "meta", Fields: []Field{{Name: "equals", Value: types.JSON(`1`)}}},
+				},
+			}},
+			Outputs: []Output{{Name: "id"}},
+		},
+		want: `{"modelName":"User","action":"findMany","query":{"arguments":{"where":{"meta":{"equals":{"$type":"Json","value":"1"},"path":["a"]}}},"selection":{"id":true}}}`,
+	}, {
+		name: "duplicate field is combined with AND",
+		query: Query{
+			Method: "findMany",
+			Model:  "User",
+			Inputs: []Input{{
+				Name: "where",
+				Fields: []Field{
+					{Name: "name", Fields: []Field{{Name: "contains", Value: "a"}}},
+					{Name: "name", Fields: []Field{{Name: "contains", Value: "b"}}},
+				},
+			}},
+			Outputs: []Output{{Name: "id"}},
+		},
+		want: `{"modelName":"User","action":"findMany","query":{"arguments":{"where":{"AND":[{"name":{"contains":"b"}}],"name":{"contains":"a"}}},"selection":{"id":true}}}`,
+	}, {
+		name: "operators",
+		query: Query{
+			Method: "findMany",
+			Model:  "User",
+			Inputs: []Input{{
+				Name: "where",
+				Fields: []Field{
+					{Name: "OR", List: true, WrapList: true, Fields: []Field{
+						{Name: "name", Value: "a"},
+						{Name: "name", Value: "b"},
+					}},
+					{Name: "OR", List: true, WrapList: true, Fields: []Field{
+						{Name: "email", Value: "c"},
+					}},
+				},
+			}},
+			Outputs: []Output{{Name: "id"}},
+		},
+		want: `{"modelName":"User","action":"findMany","query":{"arguments":{"where":{"AND":[{"OR":[{"email":"c"}]}],"OR":[{"name":"a"},{"name":"b"}]}},"selection":{"id":true}}}`,
+	}, {
+		name: "link multiple records",
+		query: Query{
+			Method: "updateOne",
+			Model:  "User",
+			Inputs: []Input{{
+				Name: "data",
+				Fields: []Field{{
+					Name: "posts",
+					Fields: []Field{
+						{Name: "connect", List: true, WrapList: true, Fields: []Field{{Name: "id", Value: "a"}}},
+						{Name: "connect", List: true, WrapList: true, Fields: []Field{{Name: "id", Value: "b"}}},
+					},
+				}},
+			}},
+			Outputs: []Output{{Name: "id"}},
+		},
+		want: `{"modelName":"User","action":"updateOne","query":{"arguments":{"data":{"posts":{"connect":[{"id":"a"},{"id":"b"}]}}},"selection":{"id":true}}}`,
+	}, {
+		name: "raw query",
+		query: Query{
+			Method: "queryRaw",
+			Inputs: []Input{
+				{Name: "query", Value: "SELECT 1"},
+				{Name: "parameters", Value: "[]"},
+			},
+		},
+		want: `{"action":"queryRaw","query":{"arguments":{"parameters":"[]","query":"SELECT 1"},"selection":{"$scalars":true}}}`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.query.BuildJSON())
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
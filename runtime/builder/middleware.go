@@ -0,0 +1,92 @@
+package builder
+
+import (
+	"context"
+	"sync"
+
+	"github.com/steebchen/prisma-client-go/engine"
+)
+
+// Request describes a request to the query engine which is passed through the middleware chain
+type Request struct {
+	// Model contains the Prisma model name, and is empty for raw queries and batches
+	Model string
+
+	// Method describes the action, such as "findUnique" or "queryRaw", and is "batch" for batches
+	Method string
+
+	// Operation describes the PQL operation: query or mutation
+	Operation string
+
+	// Query contains the query. It is empty for batches.
+	Query Query
+
+	// Batch contains the queries of a batch
+	Batch []Query
+
+	// TransactionBatch is true for batches which run in a single database transaction, so they are applied either
+	// completely or not at all
+	TransactionBatch bool
+
+	// Transaction is true for requests which are sent as part of an interactive transaction
+	Transaction bool
+
+	// Result points to the value which the response is decoded into, and is filled once the next handler returned
+	// without an error
+	Result interface{}
+}
+
+// Handler sends a request to the query engine
+type Handler func(ctx context.Context, req *Request) error
+
+// Middleware wraps a handler to run code before and after each request, or to modify or short-circuit it
+type Middleware func(next Handler) Handler
+
+// Middlewares holds a middleware chain, which is run around all requests sent through the client
+type Middlewares struct {
+	list []Middleware
+	mu   sync.RWMutex
+
+	// parent is set for clients of interactive transactions, which use the middlewares of their parent client
+	parent *Middlewares
+}
+
+// Use appends middlewares to the chain. The first registered middleware is the outermost one.
+func (m *Middlewares) Use(middlewares ...Middleware) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.list = append(m.list, middlewares...)
+}
+
+// Wrap returns a handler which runs h through the middleware chain
+func (m *Middlewares) Wrap(h Handler) Handler {
+	m.mu.RLock()
+	for i := len(m.list) - 1; i >= 0; i-- {
+		h = m.list[i](h)
+	}
+	m.mu.RUnlock()
+
+	if m.parent == nil {
+		return h
+	}
+
+	h = m.parent.Wrap(h)
+	return func(ctx context.Context, req *Request) error {
+		req.Transaction = true
+		return h(ctx, req)
+	}
+}
+
+// Transaction returns the middlewares of a client of an interactive transaction. The middlewares of m run around
+// its requests, which are marked as part of a transaction.
+func (m *Middlewares) Transaction() *Middlewares {
+	return &Middlewares{parent: m}
+}
+
+// Chain wraps h in the middleware chain of the engine, if it has one
+func Chain(e engine.Engine, h Handler) Handler {
+	if m, ok := e.(interface{ Wrap(Handler) Handler }); ok {
+		return m.Wrap(h)
+	}
+	return h
+}
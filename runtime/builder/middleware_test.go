@@ -0,0 +1,106 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/steebchen/prisma-client-go/engine"
+)
+
+type middlewareEngine struct {
+	engine.Engine
+	*Middlewares
+}
+
+func TestChain(t *testing.T) {
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, req *Request) error {
+				calls = append(calls, name+" before "+req.Method)
+				err := next(ctx, req)
+				calls = append(calls, name+" after")
+				return err
+			}
+		}
+	}
+
+	e := middlewareEngine{Middlewares: &Middlewares{}}
+	e.Use(record("a"), record("b"))
+
+	errTerminal := errors.New("terminal")
+	handler := Chain(e, func(ctx context.Context, req *Request) error {
+		calls = append(calls, "handler")
+		return errTerminal
+	})
+
+	err := handler(context.Background(), &Request{Method: "findUnique"})
+
+	assert.Equal(t, errTerminal, err)
+	assert.Equal(t, []string{
+		"a before findUnique",
+		"b before findUnique",
+		"handler",
+		"b after",
+		"a after",
+	}, calls)
+}
+
+func TestChain_shortCircuit(t *testing.T) {
+	e := middlewareEngine{Middlewares: &Middlewares{}}
+	e.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) error {
+			*req.Result.(*string) = "cached"
+			return nil
+		}
+	})
+
+	var result string
+	err := Chain(e, func(ctx context.Context, req *Request) error {
+		t.Fatal("handler should not be called")
+		return nil
+	})(context.Background(), &Request{Result: &result})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "cached", result)
+}
+
+func TestChain_noMiddlewares(t *testing.T) {
+	called := false
+	err := Chain(nil, func(ctx context.Context, req *Request) error {
+		called = true
+		return nil
+	})(context.Background(), &Request{})
+
+	assert.NoError(t, err)
+	assert.True(t, called)
+}
+
+func TestMiddlewares_Transaction(t *testing.T) {
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, req *Request) error {
+				calls = append(calls, fmt.Sprintf("%s %t", name, req.Transaction))
+				return next(ctx, req)
+			}
+		}
+	}
+
+	parent := &Middlewares{}
+	parent.Use(record("parent"))
+
+	tx := parent.Transaction()
+	tx.Use(record("tx"))
+
+	err := tx.Wrap(func(ctx context.Context, req *Request) error {
+		return nil
+	})(context.Background(), &Request{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"parent true", "tx true"}, calls)
+}
@@ -0,0 +1,82 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/steebchen/prisma-client-go/engine"
+	"github.com/steebchen/prisma-client-go/runtime/raw"
+)
+
+// Ping checks that the query engine is alive and sends a trivial query to the database, e.g. SELECT 1
+func (c *Lifecycle) Ping(ctx context.Context) error {
+	if err := c.Engine.Ping(ctx); err != nil {
+		return fmt.Errorf("ping query engine: %w", err)
+	}
+
+	r := raw.Raw{Engine: c.Engine}
+	var result json.RawMessage
+	var err error
+	if c.Provider == "mongodb" {
+		err = r.RunCommandRaw(`{"ping": 1}`).Exec(ctx, &result)
+	} else {
+		err = r.QueryRaw("SELECT 1").Exec(ctx, &result)
+	}
+	if err != nil {
+		return fmt.Errorf("ping database: %w", err)
+	}
+
+	return nil
+}
+
+// Status returns the state, process id, uptime and version of the query engine
+func (c *Lifecycle) Status() engine.Status {
+	return c.Engine.Status()
+}
+
+// LivenessHandler returns a http.Handler which responds with 200 if the query engine is alive, and with 503
+// otherwise. It doesn't query the database, so a database outage doesn't cause the application to be restarted.
+func (c *Lifecycle) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, c.Status(), c.Engine.Ping(r.Context()))
+	})
+}
+
+// ReadinessHandler returns a http.Handler which responds with 200 if the query engine is alive and the database
+// can be queried, and with 503 otherwise.
+func (c *Lifecycle) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, c.Status(), c.Ping(r.Context()))
+	})
+}
+
+type healthResponse struct {
+	State   engine.State `json:"state"`
+	PID     int          `json:"pid,omitempty"`
+	Uptime  string       `json:"uptime,omitempty"`
+	Version string       `json:"version,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+func writeHealth(w http.ResponseWriter, status engine.Status, err error) {
+	response := healthResponse{
+		State:   status.State,
+		PID:     status.PID,
+		Version: status.Version,
+	}
+	if status.Uptime > 0 {
+		response.Uptime = status.Uptime.Round(time.Second).String()
+	}
+	code := http.StatusOK
+	if err != nil {
+		response.Error = err.Error()
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,136 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/steebchen/prisma-client-go/engine"
+	"github.com/steebchen/prisma-client-go/engine/protocol"
+)
+
+type fakeEngine struct {
+	engine.Engine
+	pingErr  error
+	queryErr error
+	query    string
+}
+
+func (e *fakeEngine) Ping(context.Context) error {
+	return e.pingErr
+}
+
+func (e *fakeEngine) Status() engine.Status {
+	if e.pingErr != nil {
+		return engine.Status{State: engine.StateCrashed}
+	}
+	return engine.Status{State: engine.StateConnected, PID: 42, Uptime: time.Minute, Version: "abc"}
+}
+
+func (e *fakeEngine) Do(_ context.Context, payload interface{}, _ interface{}) error {
+	e.query = payload.(protocol.GQLRequest).Query
+	return e.queryErr
+}
+
+func (e *fakeEngine) Protocol() engine.Protocol {
+	return engine.ProtocolGraphQL
+}
+
+func (e *fakeEngine) TracerProvider() trace.TracerProvider {
+	return noop.NewTracerProvider()
+}
+
+func TestLifecycle_Ping(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		engine   *fakeEngine
+		query    string
+		err      string
+	}{{
+		name:     "sql",
+		provider: "postgresql",
+		engine:   &fakeEngine{},
+		query:    `SELECT 1`,
+	}, {
+		name:     "mongodb",
+		provider: "mongodb",
+		engine:   &fakeEngine{},
+		query:    `runCommandRaw`,
+	}, {
+		name:     "engine down",
+		provider: "postgresql",
+		engine:   &fakeEngine{pingErr: errors.New("query engine crashed")},
+		err:      "ping query engine: query engine crashed",
+	}, {
+		name:     "database down",
+		provider: "postgresql",
+		engine:   &fakeEngine{queryErr: errors.New("can't reach database server")},
+		query:    `SELECT 1`,
+		err:      "ping database",
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Lifecycle{Engine: tt.engine, Provider: tt.provider}
+			err := c.Ping(context.Background())
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Contains(t, tt.engine.query, tt.query)
+		})
+	}
+}
+
+func TestLifecycle_Handlers(t *testing.T) {
+	tests := []struct {
+		name      string
+		engine    *fakeEngine
+		liveness  int
+		readiness int
+		body      string
+	}{{
+		name:      "healthy",
+		engine:    &fakeEngine{},
+		liveness:  200,
+		readiness: 200,
+		body:      `{"state":"connected","pid":42,"uptime":"1m0s","version":"abc"}` + "\n",
+	}, {
+		name:      "database down",
+		engine:    &fakeEngine{queryErr: errors.New("can't reach database server")},
+		liveness:  200,
+		readiness: 503,
+	}, {
+		name:      "engine crashed",
+		engine:    &fakeEngine{pingErr: errors.New("query engine crashed")},
+		liveness:  503,
+		readiness: 503,
+		body:      `{"state":"crashed","error":"query engine crashed"}` + "\n",
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Lifecycle{Engine: tt.engine, Provider: "postgresql"}
+
+			rec := httptest.NewRecorder()
+			c.LivenessHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/livez", nil))
+			assert.Equal(t, tt.liveness, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			if tt.body != "" {
+				assert.Equal(t, tt.body, rec.Body.String())
+			}
+
+			rec = httptest.NewRecorder()
+			c.ReadinessHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+			assert.Equal(t, tt.readiness, rec.Code)
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 
 type Lifecycle struct {
 	Engine engine.Engine
+	// Provider is the datasource provider, which is used to send a database query on Ping
+	Provider string
 }
 
 // Connect connects to the Prisma query engine. Required to call before accessing data.
@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/steebchen/prisma-client-go/engine"
+)
+
+// Metrics contains the query engine metrics
+type Metrics struct {
+	Counters   []Counter   `json:"counters"`
+	Gauges     []Gauge     `json:"gauges"`
+	Histograms []Histogram `json:"histograms"`
+}
+
+// Counter is a metric which only increases, e.g. prisma_client_queries_total
+type Counter struct {
+	Key         string            `json:"key"`
+	Labels      map[string]string `json:"labels"`
+	Value       int64             `json:"value"`
+	Description string            `json:"description"`
+}
+
+// Gauge is a metric which can increase and decrease, e.g. prisma_pool_connections_open
+type Gauge struct {
+	Key         string            `json:"key"`
+	Labels      map[string]string `json:"labels"`
+	Value       float64           `json:"value"`
+	Description string            `json:"description"`
+}
+
+// Histogram is a metric which counts values in buckets, e.g. prisma_client_queries_duration_histogram_ms
+type Histogram struct {
+	Key         string            `json:"key"`
+	Labels      map[string]string `json:"labels"`
+	Value       HistogramValue    `json:"value"`
+	Description string            `json:"description"`
+}
+
+// HistogramValue contains the buckets, the sum and the count of all recorded values
+type HistogramValue struct {
+	Buckets []Bucket `json:"buckets"`
+	Sum     float64  `json:"sum"`
+	Count   int64    `json:"count"`
+}
+
+// Bucket contains the number of values which are less than or equal to the upper bound
+type Bucket struct {
+	UpperBound float64
+	Count      int64
+}
+
+// UnmarshalJSON parses a bucket from the query engine format [upperBound, count]
+func (b *Bucket) UnmarshalJSON(data []byte) error {
+	var raw [2]json.Number
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("bucket: %w", err)
+	}
+	upperBound, err := raw[0].Float64()
+	if err != nil {
+		return fmt.Errorf("bucket upper bound: %w", err)
+	}
+	count, err := raw[1].Int64()
+	if err != nil {
+		return fmt.Errorf("bucket count: %w", err)
+	}
+	b.UpperBound = upperBound
+	b.Count = count
+	return nil
+}
+
+// MarshalJSON returns the bucket in the query engine format [upperBound, count]
+func (b Bucket) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]interface{}{b.UpperBound, b.Count})
+}
+
+// Counter returns the first counter with the given key
+func (m *Metrics) Counter(key string) (Counter, bool) {
+	for _, c := range m.Counters {
+		if c.Key == key {
+			return c, true
+		}
+	}
+	return Counter{}, false
+}
+
+// Gauge returns the first gauge with the given key
+func (m *Metrics) Gauge(key string) (Gauge, bool) {
+	for _, g := range m.Gauges {
+		if g.Key == key {
+			return g, true
+		}
+	}
+	return Gauge{}, false
+}
+
+// Histogram returns the first histogram with the given key
+func (m *Metrics) Histogram(key string) (Histogram, bool) {
+	for _, h := range m.Histograms {
+		if h.Key == key {
+			return h, true
+		}
+	}
+	return Histogram{}, false
+}
+
+// Get returns the metrics of the query engine
+func Get(ctx context.Context, e engine.Engine) (*Metrics, error) {
+	body, err := e.Metrics(ctx, engine.MetricsFormatJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Metrics
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, fmt.Errorf("metrics unmarshal: %w", err)
+	}
+	return &m, nil
+}
+
+// Prometheus returns the metrics of the query engine in the Prometheus text format
+func Prometheus(ctx context.Context, e engine.Engine) (string, error) {
+	body, err := e.Metrics(ctx, engine.MetricsFormatPrometheus)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// Handler returns a http.Handler which serves the query engine metrics in the Prometheus text format,
+// or in JSON when requested with ?format=json.
+func Handler(e engine.Engine) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format := engine.MetricsFormatPrometheus
+		contentType := "text/plain; version=0.0.4; charset=utf-8"
+		if engine.MetricsFormat(r.URL.Query().Get("format")) == engine.MetricsFormatJSON {
+			format = engine.MetricsFormatJSON
+			contentType = "application/json"
+		}
+
+		body, err := e.Metrics(r.Context(), format)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(body)
+	})
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/steebchen/prisma-client-go/engine"
+)
+
+const jsonMetrics = `{"counters":[{"key":"prisma_client_queries_total","labels":{},"value":5,"description":"Total number of Prisma Client queries executed"}],"gauges":[{"key":"prisma_pool_connections_open","labels":{},"value":1,"description":"Number of currently open Pool Connections"}],"histograms":[{"key":"prisma_client_queries_duration_histogram_ms","labels":{},"value":{"buckets":[[0,0],[1,2],[5,3]],"sum":7.5,"count":5},"description":"Histogram of the duration of all executed Prisma Client queries in ms"}]}`
+
+const prometheusMetrics = "prisma_pool_connections_open 1\n"
+
+type fakeEngine struct {
+	engine.Engine
+}
+
+func (fakeEngine) Metrics(_ context.Context, format engine.MetricsFormat) ([]byte, error) {
+	if format == engine.MetricsFormatJSON {
+		return []byte(jsonMetrics), nil
+	}
+	return []byte(prometheusMetrics), nil
+}
+
+func TestGet(t *testing.T) {
+	m, err := Get(context.Background(), fakeEngine{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counter, ok := m.Counter("prisma_client_queries_total")
+	assert.True(t, ok)
+	assert.Equal(t, int64(5), counter.Value)
+
+	gauge, ok := m.Gauge("prisma_pool_connections_open")
+	assert.True(t, ok)
+	assert.Equal(t, float64(1), gauge.Value)
+
+	histogram, ok := m.Histogram("prisma_client_queries_duration_histogram_ms")
+	assert.True(t, ok)
+	assert.Equal(t, HistogramValue{
+		Buckets: []Bucket{{0, 0}, {1, 2}, {5, 3}},
+		Sum:     7.5,
+		Count:   5,
+	}, histogram.Value)
+
+	_, ok = m.Gauge("unknown")
+	assert.False(t, ok)
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		contentType string
+		body        string
+	}{{
+		name:        "prometheus",
+		url:         "/metrics",
+		contentType: "text/plain; version=0.0.4; charset=utf-8",
+		body:        prometheusMetrics,
+	}, {
+		name:        "json",
+		url:         "/metrics?format=json",
+		contentType: "application/json",
+		body:        jsonMetrics,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Handler(fakeEngine{}).ServeHTTP(rec, httptest.NewRequest("GET", tt.url, nil))
+			assert.Equal(t, tt.contentType, rec.Header().Get("Content-Type"))
+			assert.Equal(t, tt.body, rec.Body.String())
+		})
+	}
+}
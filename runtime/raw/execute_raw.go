@@ -26,7 +26,7 @@ func (r ExecuteExec) ExtractQuery() builder.Query {
 func (r ExecuteExec) Tx() TxExecuteResult {
 	v := NewTxExecuteResult()
 	v.query = r.query
-	v.query.TxResult = make(chan []byte, 1)
+	v.query.TxResult = make(chan builder.TxResponse, 1)
 	return v
 }
 
@@ -57,12 +57,18 @@ func (r TxExecuteResult) ExtractQuery() builder.Query {
 
 func (r TxExecuteResult) IsTx() {}
 
+// Result returns the result of the query, or nil if the query failed. Use Err to get the error.
 func (r TxExecuteResult) Result() *types.BatchResult {
 	var v int
 	if err := r.result.Get(r.query.TxResult, &v); err != nil {
-		panic(err)
+		return nil
 	}
 	return &types.BatchResult{
 		Count: v,
 	}
 }
+
+// Err returns the error of the query, e.g. when it failed in a non-transactional batch
+func (r TxExecuteResult) Err() error {
+	return r.result.Err(r.query.TxResult)
+}
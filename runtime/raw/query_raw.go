@@ -26,7 +26,7 @@ func (r QueryExec) ExtractQuery() builder.Query {
 func (r QueryExec) Tx() TxQueryResult {
 	v := NewTxQueryResult()
 	v.query = r.query
-	v.query.TxResult = make(chan []byte, 1)
+	v.query.TxResult = make(chan builder.TxResponse, 1)
 	return v
 }
 
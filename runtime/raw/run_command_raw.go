@@ -24,7 +24,7 @@ func (r RunCommandExec) ExtractQuery() builder.Query {
 func (r RunCommandExec) Tx() TxQueryResult {
 	v := NewTxQueryResult()
 	v.query = r.query
-	v.query.TxResult = make(chan []byte, 1)
+	v.query.TxResult = make(chan builder.TxResponse, 1)
 	return v
 }
 
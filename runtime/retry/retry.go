@@ -0,0 +1,146 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"syscall"
+	"time"
+
+	"github.com/steebchen/prisma-client-go/engine"
+	"github.com/steebchen/prisma-client-go/logger"
+	"github.com/steebchen/prisma-client-go/runtime/builder"
+	"github.com/steebchen/prisma-client-go/runtime/types"
+)
+
+// Policy describes how requests are retried on transient errors
+type Policy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one
+	MaxAttempts int
+
+	// MinBackoff is the delay before the first retry, which is doubled for each further retry
+	MinBackoff time.Duration
+
+	// MaxBackoff caps the delay between retries. It is not capped if zero.
+	MaxBackoff time.Duration
+
+	// Retryable classifies whether a failed request can be retried. Defaults to IsTransient.
+	Retryable func(err error) bool
+}
+
+// DefaultPolicy retries up to two times with a backoff starting at 100ms
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts: 3,
+		MinBackoff:  100 * time.Millisecond,
+		MaxBackoff:  2 * time.Second,
+		Retryable:   IsTransient,
+	}
+}
+
+// IsTransient returns whether err is caused by a transient failure: the database can't be reached (P1001), the
+// connection was closed (P1017), a write conflict or deadlock occurred (P2034) or the query engine is unavailable
+func IsTransient(err error) bool {
+	var pe *types.PrismaError
+	if errors.As(err, &pe) {
+		switch pe.Code {
+		case types.CodeDatabaseUnreachable, types.CodeConnectionClosed, types.CodeTransactionConflict:
+			return true
+		}
+		return false
+	}
+
+	var crashed *engine.EngineCrashedError
+	if errors.As(err, &crashed) {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET)
+}
+
+type idempotentKey struct{}
+
+// WithIdempotent marks all writes sent with the returned context as idempotent, so they are retried as well
+func WithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// IsIdempotent returns whether ctx was marked with WithIdempotent
+func IsIdempotent(ctx context.Context) bool {
+	v, _ := ctx.Value(idempotentKey{}).(bool)
+	return v
+}
+
+// Middleware retries failed requests according to the policy. Only reads, batch transactions and writes marked with
+// WithIdempotent are retried. Batch transactions are retried as a whole, and requests in interactive transactions
+// are never retried.
+func Middleware(p Policy) builder.Middleware {
+	if p.Retryable == nil {
+		p.Retryable = IsTransient
+	}
+	return func(next builder.Handler) builder.Handler {
+		return func(ctx context.Context, req *builder.Request) error {
+			err := next(ctx, req)
+			if err == nil || !canRetry(ctx, req) {
+				return err
+			}
+
+			for attempt := 1; attempt < p.MaxAttempts && p.Retryable(err) && ctx.Err() == nil; attempt++ {
+				delay := p.backoff(attempt)
+				logger.Debug.Printf("retrying %s %s in %s after attempt %d failed: %s", req.Model, req.Method, delay, attempt, err)
+
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+					return err
+				}
+
+				err = next(ctx, req)
+				if err == nil {
+					return nil
+				}
+			}
+			return err
+		}
+	}
+}
+
+// backoff returns the delay before the given retry with a random jitter of up to half of the delay
+func (p Policy) backoff(retry int) time.Duration {
+	d := p.MinBackoff
+	// stop doubling before the delay overflows, as it's not capped if MaxBackoff is zero
+	for i := 1; i < retry && d <= math.MaxInt64/2; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// canRetry returns whether a request only reads data, runs in a batch transaction or was marked as idempotent
+func canRetry(ctx context.Context, req *builder.Request) bool {
+	if req.Transaction {
+		return false
+	}
+	// a failed batch transaction was rolled back, so it can be sent again
+	if req.TransactionBatch {
+		return true
+	}
+	if IsIdempotent(ctx) {
+		return true
+	}
+	if len(req.Batch) > 0 {
+		for _, q := range req.Batch {
+			if q.Operation != "query" {
+				return false
+			}
+		}
+		return true
+	}
+	return req.Operation == "query"
+}
@@ -0,0 +1,198 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/steebchen/prisma-client-go/engine"
+	"github.com/steebchen/prisma-client-go/engine/protocol"
+	"github.com/steebchen/prisma-client-go/runtime/builder"
+	"github.com/steebchen/prisma-client-go/runtime/types"
+)
+
+func userFacingError(code string) error {
+	return fmt.Errorf("user facing error: %w", types.NewError(&protocol.UserFacingError{ErrorCode: code}))
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{{
+		name: "database unreachable",
+		err:  userFacingError(types.CodeDatabaseUnreachable),
+		want: true,
+	}, {
+		name: "connection closed",
+		err:  userFacingError(types.CodeConnectionClosed),
+		want: true,
+	}, {
+		name: "transaction conflict",
+		err:  userFacingError(types.CodeTransactionConflict),
+		want: true,
+	}, {
+		name: "unique constraint",
+		err:  userFacingError(types.CodeUniqueConstraint),
+		want: false,
+	}, {
+		name: "engine crashed",
+		err:  fmt.Errorf("request failed: %w", &engine.EngineCrashedError{}),
+		want: true,
+	}, {
+		name: "connection refused",
+		err:  fmt.Errorf("raw post: %w", syscall.ECONNREFUSED),
+		want: true,
+	}, {
+		name: "not found",
+		err:  types.ErrNotFound,
+		want: false,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsTransient(tt.err))
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	transient := userFacingError(types.CodeTransactionConflict)
+
+	read := builder.Query{Operation: "query"}
+	write := builder.Query{Operation: "mutation"}
+
+	tests := []struct {
+		name       string
+		req        builder.Request
+		idempotent bool
+		errs       []error
+		wantErr    error
+		wantCalls  int
+	}{{
+		name:      "read succeeds after retry",
+		req:       builder.Request{Operation: "query"},
+		errs:      []error{transient, nil},
+		wantCalls: 2,
+	}, {
+		name:      "read gives up after max attempts",
+		req:       builder.Request{Operation: "query"},
+		errs:      []error{transient, transient, transient, transient},
+		wantErr:   transient,
+		wantCalls: 3,
+	}, {
+		name:      "write is not retried",
+		req:       builder.Request{Operation: "mutation"},
+		errs:      []error{transient, nil},
+		wantErr:   transient,
+		wantCalls: 1,
+	}, {
+		name:       "idempotent write is retried",
+		req:        builder.Request{Operation: "mutation"},
+		idempotent: true,
+		errs:       []error{transient, nil},
+		wantCalls:  2,
+	}, {
+		name:      "non-transient error is not retried",
+		req:       builder.Request{Operation: "query"},
+		errs:      []error{types.ErrNotFound, nil},
+		wantErr:   types.ErrNotFound,
+		wantCalls: 1,
+	}, {
+		name:      "read batch is retried as a unit",
+		req:       builder.Request{Operation: "mutation", Batch: []builder.Query{read, read}},
+		errs:      []error{transient, nil},
+		wantCalls: 2,
+	}, {
+		name:      "batch with writes is not retried",
+		req:       builder.Request{Operation: "mutation", Batch: []builder.Query{read, write}},
+		errs:      []error{transient, nil},
+		wantErr:   transient,
+		wantCalls: 1,
+	}, {
+		name:      "batch transaction with writes is retried as a unit",
+		req:       builder.Request{Operation: "mutation", Batch: []builder.Query{read, write}, TransactionBatch: true},
+		errs:      []error{transient, nil},
+		wantCalls: 2,
+	}, {
+		name:      "batch transaction in an interactive transaction is not retried",
+		req:       builder.Request{Operation: "mutation", Batch: []builder.Query{write}, TransactionBatch: true, Transaction: true},
+		errs:      []error{transient, nil},
+		wantErr:   transient,
+		wantCalls: 1,
+	}, {
+		name:       "interactive transaction is not retried",
+		req:        builder.Request{Operation: "query", Transaction: true},
+		idempotent: true,
+		errs:       []error{transient, nil},
+		wantErr:    transient,
+		wantCalls:  1,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := DefaultPolicy()
+			policy.MinBackoff = time.Millisecond
+
+			calls := 0
+			handler := Middleware(policy)(func(ctx context.Context, req *builder.Request) error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			})
+
+			ctx := context.Background()
+			if tt.idempotent {
+				ctx = WithIdempotent(ctx)
+			}
+
+			err := handler(ctx, &tt.req)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantCalls, calls)
+		})
+	}
+}
+
+func TestMiddleware_cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	handler := Middleware(Policy{MaxAttempts: 3, MinBackoff: time.Hour})(func(ctx context.Context, req *builder.Request) error {
+		calls++
+		cancel()
+		return &engine.EngineCrashedError{}
+	})
+
+	err := handler(ctx, &builder.Request{Operation: "query"})
+
+	assert.True(t, errors.As(err, new(*engine.EngineCrashedError)))
+	assert.Equal(t, 1, calls)
+}
+
+func TestPolicy_backoff(t *testing.T) {
+	p := Policy{MinBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+
+	for retry, max := range map[int]time.Duration{1: 100, 2: 200, 3: 300, 10: 300} {
+		max *= time.Millisecond
+		d := p.backoff(retry)
+		assert.GreaterOrEqual(t, d, max/2, "retry %d", retry)
+		assert.LessOrEqual(t, d, max, "retry %d", retry)
+	}
+}
+
+func TestPolicy_backoffUncapped(t *testing.T) {
+	p := Policy{MinBackoff: 100 * time.Millisecond}
+
+	// the delay keeps growing without overflowing when MaxBackoff isn't set
+	prev := p.MinBackoff / 2
+	for _, retry := range []int{1, 10, 40, 64, 100, 1000} {
+		d := p.backoff(retry)
+		assert.GreaterOrEqual(t, d, prev, "retry %d", retry)
+		prev = d / 2
+	}
+}
@@ -0,0 +1,86 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/steebchen/prisma-client-go/engine"
+	"github.com/steebchen/prisma-client-go/engine/protocol"
+	"github.com/steebchen/prisma-client-go/runtime/builder"
+)
+
+// Batch sends independent queries in a single request, but not in a database transaction
+func (r TX) Batch(queries ...Transaction) BatchExec {
+	return BatchExec{
+		tx:      r,
+		queries: queries,
+	}
+}
+
+type BatchExec struct {
+	queries []Transaction
+	tx      TX
+}
+
+// Exec sends all queries in a single request without a database transaction. Each query succeeds or fails on its
+// own; the error of a failed query is returned when reading its result. Exec only returns an error when the batch
+// could not be sent.
+func (r BatchExec) Exec(ctx context.Context) (err error) {
+	ctx, span := r.tx.Engine.TracerProvider().Tracer(engine.TracerName).Start(ctx, "prisma:client:batch",
+		trace.WithAttributes(
+			attribute.Int("prisma.batch_size", len(r.queries)),
+		),
+	)
+	defer func() {
+		engine.EndSpan(span, err)
+	}()
+
+	payload, err := batchPayload(r.tx.Engine, r.queries, false, "")
+	if err != nil {
+		return err
+	}
+
+	queries := make([]builder.Query, len(r.queries))
+	for i, q := range r.queries {
+		queries[i] = q.ExtractQuery()
+		//goland:noinspection GoDeferInLoop
+		defer close(queries[i].TxResult)
+	}
+
+	var result protocol.GQLBatchResponse
+	handler := builder.Chain(r.tx.Engine, func(ctx context.Context, req *builder.Request) error {
+		// a middleware may call next more than once, e.g. to retry
+		result = protocol.GQLBatchResponse{}
+		if err := r.tx.Engine.Batch(ctx, payload, &result); err != nil {
+			return fmt.Errorf("could not send batch: %w", err)
+		}
+		if len(result.Errors) > 0 {
+			return engine.ResponseError(result.Errors[0])
+		}
+		if len(result.Result) != len(queries) {
+			return fmt.Errorf("expected %d batch results but got %d", len(queries), len(result.Result))
+		}
+		return nil
+	})
+
+	if err := handler(ctx, &builder.Request{
+		Method:    "batch",
+		Operation: "mutation",
+		Batch:     queries,
+		Result:    &result,
+	}); err != nil {
+		return err
+	}
+
+	for i, inner := range result.Result {
+		if len(inner.Errors) > 0 {
+			queries[i].TxResult <- builder.TxResponse{Err: engine.ResponseError(inner.Errors[0])}
+			continue
+		}
+		queries[i].TxResult <- builder.TxResponse{Data: inner.Data.Result}
+	}
+	return nil
+}
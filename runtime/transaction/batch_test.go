@@ -0,0 +1,132 @@
+package transaction
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/steebchen/prisma-client-go/engine"
+	"github.com/steebchen/prisma-client-go/engine/protocol"
+	"github.com/steebchen/prisma-client-go/runtime/builder"
+	"github.com/steebchen/prisma-client-go/runtime/types"
+)
+
+type batchEngine struct {
+	engine.Engine
+	payload  interface{}
+	response protocol.GQLBatchResponse
+	err      error
+}
+
+func (e *batchEngine) Batch(ctx context.Context, payload interface{}, into interface{}) error {
+	e.payload = payload
+	*into.(*protocol.GQLBatchResponse) = e.response
+	return e.err
+}
+
+func (e *batchEngine) Protocol() engine.Protocol {
+	return engine.ProtocolGraphQL
+}
+
+func (e *batchEngine) TracerProvider() trace.TracerProvider {
+	return noop.NewTracerProvider()
+}
+
+type txQuery struct {
+	query  builder.Query
+	result *Result
+}
+
+func newTxQuery(method string) txQuery {
+	q := builder.NewQuery()
+	q.Operation = "mutation"
+	q.Method = method
+	q.Model = "User"
+	q.Outputs = []builder.Output{{Name: "id"}}
+	q.TxResult = make(chan builder.TxResponse, 1)
+	return txQuery{query: q, result: &Result{}}
+}
+
+func (q txQuery) IsTx() {}
+
+func (q txQuery) ExtractQuery() builder.Query {
+	return q.query
+}
+
+func TestBatchExec(t *testing.T) {
+	e := &batchEngine{
+		response: protocol.GQLBatchResponse{
+			Result: []protocol.GQLResponse{{
+				Data: protocol.Data{Result: []byte(`{"id":"a"}`)},
+			}, {
+				Errors: []protocol.GQLError{{
+					UserFacingError: &protocol.UserFacingError{ErrorCode: "P2025", Message: "not found"},
+				}},
+			}},
+		},
+	}
+	a, b := newTxQuery("createOne"), newTxQuery("updateOne")
+
+	assert.NoError(t, TX{Engine: e}.Batch(a, b).Exec(context.Background()))
+
+	assert.Nil(t, e.payload.(protocol.GQLBatchRequest).Transaction)
+	assert.Len(t, e.payload.(protocol.GQLBatchRequest).Batch, 2)
+
+	var user struct {
+		ID string `json:"id"`
+	}
+	assert.NoError(t, a.result.Get(a.query.TxResult, &user))
+	assert.Equal(t, "a", user.ID)
+	assert.NoError(t, a.result.Err(a.query.TxResult))
+
+	assert.ErrorIs(t, b.result.Err(b.query.TxResult), types.ErrNotFound)
+	// the error is kept for all further calls
+	assert.ErrorIs(t, b.result.Get(b.query.TxResult, &user), types.ErrNotFound)
+}
+
+func TestBatchExec_error(t *testing.T) {
+	errBatch := errors.New("connection refused")
+	e := &batchEngine{err: errBatch}
+	a := newTxQuery("createOne")
+
+	assert.ErrorIs(t, TX{Engine: e}.Batch(a).Exec(context.Background()), errBatch)
+	assert.EqualError(t, a.result.Err(a.query.TxResult), "result not fetched")
+}
+
+func TestTransactionExec_isolationLevel(t *testing.T) {
+	e := &batchEngine{
+		response: protocol.GQLBatchResponse{
+			Result: []protocol.GQLResponse{{
+				Data: protocol.Data{Result: []byte(`{"id":"a"}`)},
+			}},
+		},
+	}
+	a := newTxQuery("createOne")
+
+	tx := TX{Engine: e, Provider: "postgresql"}
+	assert.NoError(t, tx.Transaction(a).Exec(context.Background(), WithIsolationLevel(Serializable)))
+
+	// the engine reads the isolation level from the transaction object of the batch
+	body, err := json.Marshal(e.payload)
+	assert.NoError(t, err)
+	var payload struct {
+		Transaction map[string]interface{} `json:"transaction"`
+	}
+	assert.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, map[string]interface{}{"isolationLevel": "Serializable"}, payload.Transaction)
+}
+
+func TestTransactionExec_interactiveOptions(t *testing.T) {
+	e := &batchEngine{}
+	a := newTxQuery("createOne")
+
+	err := TX{Engine: e}.Transaction(a).Exec(context.Background(), WithTimeout(time.Second))
+	assert.EqualError(t, err, "max wait and timeout are only supported by interactive transactions; use InteractiveTransaction instead")
+	assert.Nil(t, e.payload)
+}
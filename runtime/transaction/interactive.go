@@ -0,0 +1,102 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/steebchen/prisma-client-go/engine"
+	"github.com/steebchen/prisma-client-go/engine/protocol"
+	"github.com/steebchen/prisma-client-go/logger"
+)
+
+// DefaultMaxWait is the default time to wait for a connection when starting an interactive transaction
+const DefaultMaxWait = 2 * time.Second
+
+// DefaultTimeout is the default time an interactive transaction may run before it's rolled back
+const DefaultTimeout = 5 * time.Second
+
+// Interactive runs fn in an interactive transaction. All queries sent through the engine passed to fn
+// are executed in the same database transaction, which is committed when fn returns nil and rolled back
+// when fn returns an error or panics.
+func (r TX) Interactive(ctx context.Context, fn func(tx engine.Engine) error, options ...Option) error {
+	o, err := newOptions(r.Provider, options)
+	if err != nil {
+		return err
+	}
+
+	if o.MaxWait == 0 {
+		o.MaxWait = DefaultMaxWait
+	}
+
+	if o.Timeout == 0 {
+		o.Timeout = DefaultTimeout
+	}
+
+	id, err := r.Engine.StartTx(ctx, protocol.TransactionStartRequest{
+		MaxWait:        int(o.MaxWait.Milliseconds()),
+		Timeout:        int(o.Timeout.Milliseconds()),
+		IsolationLevel: string(o.IsolationLevel),
+	})
+	if err != nil {
+		return fmt.Errorf("start transaction: %w", err)
+	}
+
+	logger.Debug.Printf("started interactive transaction %s", id)
+
+	rollback := func() error {
+		// roll back even if the original context was already cancelled
+		return r.Engine.RollbackTx(context.WithoutCancel(ctx), id)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if err := rollback(); err != nil {
+				logger.Info.Printf("could not roll back transaction %s after panic: %s", id, err)
+			}
+			panic(p)
+		}
+	}()
+
+	if err := fn(&txEngine{Engine: r.Engine, id: id}); err != nil {
+		if rollbackErr := rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w (rollback failed: %s)", err, rollbackErr)
+		}
+		logger.Debug.Printf("rolled back interactive transaction %s", id)
+		return err
+	}
+
+	if err := r.Engine.CommitTx(ctx, id); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	logger.Debug.Printf("committed interactive transaction %s", id)
+
+	return nil
+}
+
+// txEngine sends all requests as part of an interactive transaction
+type txEngine struct {
+	engine.Engine
+	id string
+}
+
+func (e *txEngine) Connect() error {
+	return fmt.Errorf("cannot connect a transaction client; use the parent client instead")
+}
+
+func (e *txEngine) Disconnect() error {
+	return fmt.Errorf("cannot disconnect a transaction client; use the parent client instead")
+}
+
+func (e *txEngine) Do(ctx context.Context, payload interface{}, into interface{}) error {
+	return e.Engine.Do(engine.WithTransactionID(ctx, e.id), payload, into)
+}
+
+func (e *txEngine) Batch(ctx context.Context, payload interface{}, into interface{}) error {
+	return e.Engine.Batch(engine.WithTransactionID(ctx, e.id), payload, into)
+}
+
+func (e *txEngine) StartTx(context.Context, protocol.TransactionStartRequest) (string, error) {
+	return "", fmt.Errorf("nested interactive transactions are not supported")
+}
@@ -0,0 +1,94 @@
+package transaction
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// IsolationLevel describes the isolation level of a database transaction
+type IsolationLevel string
+
+const (
+	ReadUncommitted IsolationLevel = "ReadUncommitted"
+	ReadCommitted   IsolationLevel = "ReadCommitted"
+	RepeatableRead  IsolationLevel = "RepeatableRead"
+	Snapshot        IsolationLevel = "Snapshot"
+	Serializable    IsolationLevel = "Serializable"
+)
+
+// isolationLevels contains the supported isolation levels per provider
+var isolationLevels = map[string][]IsolationLevel{
+	"postgresql":  {ReadUncommitted, ReadCommitted, RepeatableRead, Serializable},
+	"mysql":       {ReadUncommitted, ReadCommitted, RepeatableRead, Serializable},
+	"sqlserver":   {ReadUncommitted, ReadCommitted, RepeatableRead, Snapshot, Serializable},
+	"cockroachdb": {Serializable},
+	"sqlite":      {Serializable},
+	"mongodb":     {},
+}
+
+// Options configures a transaction
+type Options struct {
+	// IsolationLevel sets the isolation level; uses the database default if empty
+	IsolationLevel IsolationLevel
+
+	// MaxWait is the maximum time to wait for a connection before the transaction fails.
+	// Only supported by interactive transactions.
+	MaxWait time.Duration
+
+	// Timeout is the maximum time the transaction may run before it is rolled back.
+	// Only supported by interactive transactions.
+	Timeout time.Duration
+}
+
+type Option func(*Options)
+
+// WithIsolationLevel sets the isolation level of a transaction
+func WithIsolationLevel(level IsolationLevel) Option {
+	return func(o *Options) {
+		o.IsolationLevel = level
+	}
+}
+
+// WithMaxWait sets the maximum time to wait for a connection before the transaction fails.
+// Only supported by interactive transactions.
+func WithMaxWait(d time.Duration) Option {
+	return func(o *Options) {
+		o.MaxWait = d
+	}
+}
+
+// WithTimeout sets the maximum time a transaction may run before it is rolled back.
+// Only supported by interactive transactions.
+func WithTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = d
+	}
+}
+
+func newOptions(provider string, options []Option) (Options, error) {
+	var o Options
+	for _, option := range options {
+		option(&o)
+	}
+
+	if o.MaxWait < 0 || o.Timeout < 0 {
+		return o, fmt.Errorf("transaction max wait and timeout must not be negative")
+	}
+
+	if o.IsolationLevel == "" {
+		return o, nil
+	}
+
+	levels, ok := isolationLevels[provider]
+	if !ok {
+		// unknown provider, let the query engine validate the isolation level
+		return o, nil
+	}
+
+	if !slices.Contains(levels, o.IsolationLevel) {
+		return o, fmt.Errorf("isolation level %q is not supported by %s; supported levels: %v", o.IsolationLevel, provider, levels)
+	}
+
+	return o, nil
+}
@@ -0,0 +1,71 @@
+package transaction
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		options  []Option
+		want     Options
+		wantErr  bool
+	}{{
+		name:     "defaults",
+		provider: "postgresql",
+		want:     Options{},
+	}, {
+		name:     "all options",
+		provider: "postgresql",
+		options: []Option{
+			WithIsolationLevel(RepeatableRead),
+			WithMaxWait(time.Second),
+			WithTimeout(10 * time.Second),
+		},
+		want: Options{
+			IsolationLevel: RepeatableRead,
+			MaxWait:        time.Second,
+			Timeout:        10 * time.Second,
+		},
+	}, {
+		name:     "sqlite serializable",
+		provider: "sqlite",
+		options:  []Option{WithIsolationLevel(Serializable)},
+		want:     Options{IsolationLevel: Serializable},
+	}, {
+		name:     "sqlite read committed",
+		provider: "sqlite",
+		options:  []Option{WithIsolationLevel(ReadCommitted)},
+		wantErr:  true,
+	}, {
+		name:     "mongodb isolation level",
+		provider: "mongodb",
+		options:  []Option{WithIsolationLevel(Serializable)},
+		wantErr:  true,
+	}, {
+		name:     "unknown provider",
+		provider: "",
+		options:  []Option{WithIsolationLevel(Snapshot)},
+		want:     Options{IsolationLevel: Snapshot},
+	}, {
+		name:     "negative timeout",
+		provider: "postgresql",
+		options:  []Option{WithTimeout(-time.Second)},
+		wantErr:  true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newOptions(tt.provider, tt.options)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
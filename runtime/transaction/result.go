@@ -6,27 +6,18 @@ import (
 
 	"github.com/steebchen/prisma-client-go/engine"
 	"github.com/steebchen/prisma-client-go/logger"
+	"github.com/steebchen/prisma-client-go/runtime/builder"
 )
 
 type Result struct {
 	cache []byte
+	err   error
 }
 
-func (r *Result) Get(c <-chan []byte, v interface{}) error {
-	var res []byte
-	if r.cache != nil {
-		res = r.cache
-	} else {
-		data, ok := <-c
-		if !ok {
-			return fmt.Errorf("result not fetched")
-		}
-		data, err := engine.TransformResponse(data)
-		if err != nil {
-			return fmt.Errorf("could not transform response: %w", err)
-		}
-		res = data
-		r.cache = data
+func (r *Result) Get(c <-chan builder.TxResponse, v interface{}) error {
+	res, err := r.fetch(c)
+	if err != nil {
+		return err
 	}
 	logger.Debug.Printf("tx result: %s", res)
 	if err := json.Unmarshal(res, &v); err != nil {
@@ -34,3 +25,35 @@ func (r *Result) Get(c <-chan []byte, v interface{}) error {
 	}
 	return nil
 }
+
+// Err returns the error of the query, e.g. when it failed in a non-transactional batch
+func (r *Result) Err(c <-chan builder.TxResponse) error {
+	_, err := r.fetch(c)
+	return err
+}
+
+// fetch receives the result once and caches it
+func (r *Result) fetch(c <-chan builder.TxResponse) ([]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.cache != nil {
+		return r.cache, nil
+	}
+	response, ok := <-c
+	if !ok {
+		r.err = fmt.Errorf("result not fetched")
+		return nil, r.err
+	}
+	if response.Err != nil {
+		r.err = response.Err
+		return nil, r.err
+	}
+	data, err := engine.TransformResponse(response.Data)
+	if err != nil {
+		r.err = fmt.Errorf("could not transform response: %w", err)
+		return nil, r.err
+	}
+	r.cache = data
+	return data, nil
+}
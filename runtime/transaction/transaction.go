@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/steebchen/prisma-client-go/engine"
 	"github.com/steebchen/prisma-client-go/engine/protocol"
 	"github.com/steebchen/prisma-client-go/runtime/builder"
@@ -11,6 +14,9 @@ import (
 
 type TX struct {
 	Engine engine.Engine
+
+	// Provider contains the datasource provider, which is used to validate transaction options
+	Provider string
 }
 
 // Deprecated: use Transaction instead
@@ -23,28 +29,40 @@ type Transaction interface {
 
 func (r TX) Transaction(queries ...Transaction) Exec {
 	return Exec{
-		engine:  r.Engine,
+		tx:      r,
 		queries: queries,
 	}
 }
 
 type Exec struct {
-	queries  []Transaction
-	engine   engine.Engine
-	requests []protocol.GQLRequest
+	queries []Transaction
+	tx      TX
 }
 
-func (r Exec) Exec(ctx context.Context) error {
-	r.requests = make([]protocol.GQLRequest, len(r.queries))
-	for i, query := range r.queries {
-		str, err := query.ExtractQuery().Build()
-		if err != nil {
-			return err
-		}
-		r.requests[i] = protocol.GQLRequest{
-			Query:     str,
-			Variables: map[string]interface{}{},
-		}
+// Exec sends all queries in a single database transaction.
+// Max wait and timeout options are only supported by interactive transactions, see TX.Interactive.
+func (r Exec) Exec(ctx context.Context, options ...Option) (err error) {
+	ctx, span := r.tx.Engine.TracerProvider().Tracer(engine.TracerName).Start(ctx, "prisma:client:transaction",
+		trace.WithAttributes(
+			attribute.Int("prisma.batch_size", len(r.queries)),
+		),
+	)
+	defer func() {
+		engine.EndSpan(span, err)
+	}()
+
+	o, err := newOptions(r.tx.Provider, options)
+	if err != nil {
+		return err
+	}
+
+	if o.MaxWait != 0 || o.Timeout != 0 {
+		return fmt.Errorf("max wait and timeout are only supported by interactive transactions; use InteractiveTransaction instead")
+	}
+
+	payload, err := r.payload(o.IsolationLevel)
+	if err != nil {
+		return err
 	}
 
 	for _, q := range r.queries {
@@ -52,25 +70,83 @@ func (r Exec) Exec(ctx context.Context) error {
 		defer close(q.ExtractQuery().TxResult)
 	}
 
-	var result protocol.GQLBatchResponse
+	return r.send(ctx, payload)
+}
+
+// payload builds the batch request in the protocol of the engine
+func (r Exec) payload(isolationLevel IsolationLevel) (interface{}, error) {
+	return batchPayload(r.tx.Engine, r.queries, true, isolationLevel)
+}
+
+// batchPayload builds a batch request of all queries in the protocol of the engine
+func batchPayload(e engine.Engine, queries []Transaction, transaction bool, isolationLevel IsolationLevel) (interface{}, error) {
+	if e.Protocol() == engine.ProtocolJSON {
+		requests := make([]protocol.JSONRequest, len(queries))
+		for i, query := range queries {
+			requests[i] = query.ExtractQuery().BuildJSON()
+		}
+		payload := protocol.JSONBatchRequest{
+			Batch: requests,
+		}
+		if transaction {
+			payload.Transaction = &protocol.JSONTransaction{
+				IsolationLevel: string(isolationLevel),
+			}
+		}
+		return payload, nil
+	}
+
+	requests := make([]protocol.GQLRequest, len(queries))
+	for i, query := range queries {
+		str, err := query.ExtractQuery().Build()
+		if err != nil {
+			return nil, err
+		}
+		requests[i] = protocol.GQLRequest{
+			Query:     str,
+			Variables: map[string]interface{}{},
+		}
+	}
 	payload := protocol.GQLBatchRequest{
-		Batch:       r.requests,
-		Transaction: true,
+		Batch: requests,
 	}
-	if err := r.engine.Batch(ctx, payload, &result); err != nil {
-		return fmt.Errorf("could not send raw query: %w", err)
+	if transaction {
+		payload.Transaction = &protocol.GQLTransaction{
+			IsolationLevel: string(isolationLevel),
+		}
 	}
-	if len(result.Errors) > 0 {
-		first := result.Errors[0]
-		return fmt.Errorf("pql error: %s", first.RawMessage())
+	return payload, nil
+}
+
+func (r Exec) send(ctx context.Context, payload interface{}) error {
+	queries := make([]builder.Query, len(r.queries))
+	for i, q := range r.queries {
+		queries[i] = q.ExtractQuery()
 	}
-	for i, inner := range result.Result {
-		if len(inner.Errors) > 0 {
-			first := result.Errors[0]
-			return fmt.Errorf("pql error: %s", first.RawMessage())
+
+	var result protocol.GQLBatchResponse
+	handler := builder.Chain(r.tx.Engine, func(ctx context.Context, req *builder.Request) error {
+		// a middleware may call next more than once, e.g. to retry
+		result = protocol.GQLBatchResponse{}
+		if err := r.tx.Engine.Batch(ctx, payload, &result); err != nil {
+			return fmt.Errorf("could not send raw query: %w", err)
 		}
+		return engine.BatchError(result)
+	})
+
+	if err := handler(ctx, &builder.Request{
+		Method:           "batch",
+		Operation:        "mutation",
+		Batch:            queries,
+		TransactionBatch: true,
+		Result:           &result,
+	}); err != nil {
+		return err
+	}
 
-		r.queries[i].ExtractQuery().TxResult <- inner.Data.Result
+	// results are only delivered once, after all middlewares are done
+	for i, inner := range result.Result {
+		queries[i].TxResult <- builder.TxResponse{Data: inner.Data.Result}
 	}
 	return nil
 }
@@ -2,6 +2,7 @@ package types
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/steebchen/prisma-client-go/engine/protocol"
 )
@@ -67,3 +68,189 @@ func CheckUniqueConstraint[T F](err error) (*ErrUniqueConstraint[T], bool) {
 
 	return nil, false
 }
+
+// Error codes of user facing Prisma engine errors
+// See https://www.prisma.io/docs/orm/reference/error-reference
+const (
+	CodeDatabaseUnreachable    = "P1001"
+	CodeDatabaseTimeout        = "P1002"
+	CodeOperationTimeout       = "P1008"
+	CodeConnectionClosed       = "P1017"
+	CodeValueTooLong           = "P2000"
+	CodeUniqueConstraint       = "P2002"
+	CodeForeignKeyConstraint   = "P2003"
+	CodeNullConstraint         = "P2011"
+	CodeRecordRequiredNotFound = "P2025"
+	CodeTransactionConflict    = "P2034"
+)
+
+// PrismaError is a user facing error returned by the Prisma engine.
+// All typed errors embed it, so errors.As with a *PrismaError target works for any user facing error.
+type PrismaError struct {
+	// Code is the Prisma error code, e.g. P2002
+	Code string
+	// Message is the error message
+	Message string
+	// Meta contains additional information depending on the error code
+	Meta protocol.Meta
+
+	err *protocol.UserFacingError
+}
+
+func (e *PrismaError) Error() string {
+	return e.Message
+}
+
+func (e *PrismaError) Unwrap() error {
+	return e.err
+}
+
+func (e *PrismaError) As(target interface{}) bool {
+	if t, ok := target.(**PrismaError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
+
+// ErrDatabaseConnection is returned when the database server can't be reached, the connection
+// times out or is closed unexpectedly (P1001, P1002, P1017)
+type ErrDatabaseConnection struct {
+	PrismaError
+	DatabaseHost string
+	DatabasePort string
+}
+
+// ErrOperationTimeout is returned when an operation timed out (P1008)
+type ErrOperationTimeout struct {
+	PrismaError
+	Time string
+}
+
+// ErrValueTooLong is returned when a value is too long for the column type (P2000)
+type ErrValueTooLong struct {
+	PrismaError
+	ColumnName string
+}
+
+// ErrForeignKeyConstraint is returned on a foreign key constraint violation (P2003)
+type ErrForeignKeyConstraint struct {
+	PrismaError
+	// FieldName contains the field or constraint name, depending on the database
+	FieldName string
+}
+
+// ErrRecordRequiredNotFound is returned when an operation depends on records which don't exist (P2025),
+// e.g. when connecting a record which doesn't exist. It matches ErrNotFound when using errors.Is.
+type ErrRecordRequiredNotFound struct {
+	PrismaError
+	Cause     string
+	ModelName string
+}
+
+func (e *ErrRecordRequiredNotFound) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// ErrTransactionConflict is returned on a write conflict or a deadlock; the transaction can be retried (P2034)
+type ErrTransactionConflict struct {
+	PrismaError
+}
+
+type ErrNullConstraint[T F] struct {
+	// Message is the error message
+	Message string
+	// Fields contains the fields violating the constraint, if available
+	Fields []T
+	// Constraint contains the constraint name, if available
+	Constraint string
+}
+
+// NewError converts a user facing error into a typed error, which wraps the user facing error.
+// Errors with unknown codes are returned as a *PrismaError.
+func NewError(ufe *protocol.UserFacingError) error {
+	base := PrismaError{
+		Code:    ufe.ErrorCode,
+		Message: ufe.Message,
+		Meta:    ufe.Meta,
+		err:     ufe,
+	}
+
+	switch ufe.ErrorCode {
+	case CodeDatabaseUnreachable, CodeDatabaseTimeout, CodeConnectionClosed:
+		port := ""
+		if ufe.Meta.DatabasePort != nil {
+			port = fmt.Sprintf("%v", ufe.Meta.DatabasePort)
+		}
+		return &ErrDatabaseConnection{
+			PrismaError:  base,
+			DatabaseHost: ufe.Meta.DatabaseHost,
+			DatabasePort: port,
+		}
+	case CodeOperationTimeout:
+		return &ErrOperationTimeout{
+			PrismaError: base,
+			Time:        ufe.Meta.Time,
+		}
+	case CodeValueTooLong:
+		return &ErrValueTooLong{
+			PrismaError: base,
+			ColumnName:  ufe.Meta.ColumnName,
+		}
+	case CodeForeignKeyConstraint:
+		return &ErrForeignKeyConstraint{
+			PrismaError: base,
+			FieldName:   ufe.Meta.FieldName,
+		}
+	case CodeRecordRequiredNotFound:
+		return &ErrRecordRequiredNotFound{
+			PrismaError: base,
+			Cause:       ufe.Meta.Cause,
+			ModelName:   ufe.Meta.ModelName,
+		}
+	case CodeTransactionConflict:
+		return &ErrTransactionConflict{
+			PrismaError: base,
+		}
+	}
+
+	return &base
+}
+
+// CheckNullConstraint returns on a null constraint violation with error info
+func CheckNullConstraint[T F](err error) (*ErrNullConstraint[T], bool) {
+	var pe *PrismaError
+	if ok := errors.As(err, &pe); !ok || pe.Code != CodeNullConstraint {
+		return nil, false
+	}
+
+	info := &ErrNullConstraint[T]{
+		Message: pe.Message,
+	}
+
+	switch constraint := pe.Meta.Constraint.(type) {
+	case []interface{}:
+		for _, f := range constraint {
+			if field, ok := f.(string); ok {
+				info.Fields = append(info.Fields, T(field))
+			}
+		}
+	case string:
+		info.Constraint = constraint
+	}
+
+	return info, true
+}
+
+// As returns the first error in err's chain which is of type *T, if any.
+// It is used to check for typed errors, e.g. As[ErrForeignKeyConstraint](err)
+func As[T any, P interface {
+	*T
+	error
+}](err error) (*T, bool) {
+	var target P
+	if errors.As(err, &target) {
+		return target, true
+	}
+	return nil, false
+}
@@ -0,0 +1,83 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/steebchen/prisma-client-go/engine/protocol"
+)
+
+func userFacingError(t *testing.T, raw string) error {
+	t.Helper()
+	var ufe protocol.UserFacingError
+	if err := json.Unmarshal([]byte(raw), &ufe); err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Errorf("user facing error: %w", NewError(&ufe))
+}
+
+func TestNewError(t *testing.T) {
+	t.Run("foreign key", func(t *testing.T) {
+		err := userFacingError(t, `{"error_code":"P2003","message":"Foreign key constraint failed on the field: `+"`Post_userId_fkey (index)`"+`","meta":{"field_name":"Post_userId_fkey (index)","modelName":"Post"}}`)
+
+		info, ok := As[ErrForeignKeyConstraint](err)
+		assert.True(t, ok)
+		assert.Equal(t, "Post_userId_fkey (index)", info.FieldName)
+		assert.Equal(t, "P2003", info.Code)
+		assert.Equal(t, "Post", info.Meta.Raw["modelName"])
+
+		var pe *PrismaError
+		assert.True(t, errors.As(err, &pe))
+		assert.Equal(t, CodeForeignKeyConstraint, pe.Code)
+
+		var ufe *protocol.UserFacingError
+		assert.True(t, errors.As(err, &ufe))
+	})
+
+	t.Run("database connection", func(t *testing.T) {
+		err := userFacingError(t, `{"error_code":"P1001","message":"Can't reach database server","meta":{"database_host":"localhost","database_port":5432}}`)
+
+		var target *ErrDatabaseConnection
+		assert.True(t, errors.As(err, &target))
+		assert.Equal(t, "localhost", target.DatabaseHost)
+		assert.Equal(t, "5432", target.DatabasePort)
+	})
+
+	t.Run("record required not found", func(t *testing.T) {
+		err := userFacingError(t, `{"error_code":"P2025","message":"An operation failed","meta":{"cause":"Record to update not found."}}`)
+
+		info, ok := As[ErrRecordRequiredNotFound](err)
+		assert.True(t, ok)
+		assert.Equal(t, "Record to update not found.", info.Cause)
+		assert.True(t, IsErrNotFound(err))
+	})
+
+	t.Run("transaction conflict", func(t *testing.T) {
+		err := userFacingError(t, `{"error_code":"P2034","message":"Transaction failed due to a write conflict or a deadlock"}`)
+
+		_, ok := As[ErrTransactionConflict](err)
+		assert.True(t, ok)
+		_, ok = As[ErrForeignKeyConstraint](err)
+		assert.False(t, ok)
+	})
+
+	t.Run("null constraint", func(t *testing.T) {
+		err := userFacingError(t, `{"error_code":"P2011","message":"Null constraint violation","meta":{"constraint":["name"]}}`)
+
+		info, ok := CheckNullConstraint[string](err)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"name"}, info.Fields)
+	})
+
+	t.Run("unique constraint", func(t *testing.T) {
+		err := userFacingError(t, `{"error_code":"P2002","message":"Unique constraint failed","meta":{"target":["email"]}}`)
+
+		info, ok := CheckUniqueConstraint[string](err)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"email"}, info.Fields)
+	})
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/steebchen/prisma-client-go/test"
+)
+
+type cx = context.Context
+type Func func(t *testing.T, client *PrismaClient, ctx cx)
+
+func TestConstraintViolations(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		dbs    []test.Database
+		before []string
+		run    Func
+	}{{
+		name: "foreign key constraint violation",
+		dbs:  []test.Database{test.PostgreSQL, test.MySQL, test.SQLite},
+		// language=GraphQL
+		before: []string{`
+			mutation {
+				result: createOneUser(data: {
+					id: "user",
+					email: "john@example.com",
+					posts: {
+						create: [{ id: "post", title: "hi" }],
+					},
+				}) {
+					id
+				}
+			}
+		`},
+		run: func(t *testing.T, client *PrismaClient, ctx cx) {
+			_, err := client.User.FindUnique(
+				User.ID.Equals("user"),
+			).Delete().Exec(ctx)
+
+			violation, ok := IsErrForeignKeyConstraint(err)
+			assert.Equal(t, true, ok)
+			assert.Equal(t, "P2003", violation.Code)
+			assert.NotEmpty(t, violation.FieldName)
+		},
+	}, {
+		name: "required record not found",
+		dbs:  test.Databases,
+		run: func(t *testing.T, client *PrismaClient, ctx cx) {
+			_, err := client.Post.CreateOne(
+				Post.Title.Set("hi"),
+				Post.User.Link(
+					User.ID.Equals("does-not-exist"),
+				),
+			).Exec(ctx)
+
+			info, ok := IsErrRecordRequiredNotFound(err)
+			assert.Equal(t, true, ok)
+			assert.Equal(t, "P2025", info.Code)
+			assert.Equal(t, true, IsErrNotFound(err))
+		},
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			test.RunSerial(t, tt.dbs, func(t *testing.T, db test.Database, ctx context.Context) {
+				client := NewClient()
+				mockDBName := test.Start(t, db, client.Engine, tt.before)
+				defer test.End(t, db, client.Engine, mockDBName)
+				tt.run(t, client, context.Background())
+			})
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/steebchen/prisma-client-go/test"
+	"github.com/steebchen/prisma-client-go/test/helpers/massert"
+)
+
+type cx = context.Context
+type Func func(t *testing.T, client *PrismaClient, ctx cx)
+
+func TestInteractiveTransaction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		before []string
+		run    Func
+	}{{
+		name: "commit",
+		// language=GraphQL
+		before: []string{`
+			mutation {
+				result: createOneUser(data: {
+					id: "a",
+					email: "a",
+				}) {
+					id
+				}
+			}
+		`},
+		run: func(t *testing.T, client *PrismaClient, ctx cx) {
+			err := client.Prisma.InteractiveTransaction(ctx, func(tx *PrismaClient) error {
+				user, err := tx.User.FindUnique(User.ID.Equals("a")).Exec(ctx)
+				if err != nil {
+					return err
+				}
+
+				_, err = tx.User.CreateOne(
+					User.Email.Set(user.Email+"-copy"),
+					User.ID.Set("b"),
+				).Exec(ctx)
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := client.User.FindMany().Exec(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := []UserModel{{
+				InnerUser: InnerUser{
+					ID:    "a",
+					Email: "a",
+				},
+			}, {
+				InnerUser: InnerUser{
+					ID:    "b",
+					Email: "a-copy",
+				},
+			}}
+
+			massert.Equal(t, expected, actual)
+		},
+	}, {
+		name: "rollback on error",
+		run: func(t *testing.T, client *PrismaClient, ctx cx) {
+			err := client.Prisma.InteractiveTransaction(ctx, func(tx *PrismaClient) error {
+				_, err := tx.User.CreateOne(
+					User.Email.Set("a"),
+					User.ID.Set("a"),
+				).Exec(ctx)
+				if err != nil {
+					return err
+				}
+
+				return fmt.Errorf("abort")
+			})
+			assert.EqualError(t, err, "abort")
+
+			actual, err := client.User.FindMany().Exec(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			massert.Equal(t, []UserModel{}, actual)
+		},
+	}, {
+		name: "rollback on panic",
+		run: func(t *testing.T, client *PrismaClient, ctx cx) {
+			assert.Panics(t, func() {
+				_ = client.Prisma.InteractiveTransaction(ctx, func(tx *PrismaClient) error {
+					_, err := tx.User.CreateOne(
+						User.Email.Set("a"),
+						User.ID.Set("a"),
+					).Exec(ctx)
+					if err != nil {
+						return err
+					}
+
+					panic("abort")
+				})
+			})
+
+			actual, err := client.User.FindMany().Exec(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			massert.Equal(t, []UserModel{}, actual)
+		},
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			test.RunSerial(t, test.Databases, func(t *testing.T, db test.Database, ctx context.Context) {
+				client := NewClient()
+				mockDBName := test.Start(t, db, client.Engine, tt.before)
+				defer test.End(t, db, client.Engine, mockDBName)
+				tt.run(t, client, context.Background())
+			})
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/steebchen/prisma-client-go/test"
+	"github.com/steebchen/prisma-client-go/test/helpers/massert"
+)
+
+type cx = context.Context
+type Func func(t *testing.T, client *PrismaClient, ctx cx)
+
+func TestJSONProtocol(t *testing.T) {
+	t.Parallel()
+
+	date, _ := time.Parse(RFC3339Milli, "2000-01-01T00:00:00Z")
+
+	create := func(t *testing.T, client *PrismaClient, ctx cx) UserModel {
+		user, err := client.User.CreateOne(
+			User.Email.Set("john@example.com"),
+			User.CreatedAt.Set(date),
+			User.Bigint.Set(BigInt(9223372036854775807)),
+			User.Decimal.Set(decimal.NewFromFloat(1.5)),
+			User.Bytes.Set([]byte("abc")),
+			User.JSON.Set(JSON(`{"a":1}`)),
+			User.ID.Set("a"),
+			User.Name.Set("John"),
+		).Exec(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *user
+	}
+
+	tests := []struct {
+		name string
+		run  Func
+	}{{
+		name: "typed values",
+		run: func(t *testing.T, client *PrismaClient, ctx cx) {
+			created := create(t, client, ctx)
+
+			actual, err := client.User.FindUnique(User.ID.Equals("a")).Exec(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			massert.Equal(t, created, *actual)
+			assert.Equal(t, BigInt(9223372036854775807), actual.Bigint)
+			assert.Equal(t, "1.5", actual.Decimal.String())
+			assert.Equal(t, []byte("abc"), actual.Bytes)
+			assert.JSONEq(t, `{"a":1}`, string(actual.JSON))
+			assert.Equal(t, date, actual.CreatedAt.UTC())
+		},
+	}, {
+		name: "relations",
+		run: func(t *testing.T, client *PrismaClient, ctx cx) {
+			create(t, client, ctx)
+
+			_, err := client.Post.CreateOne(
+				Post.Title.Set("a"),
+				Post.Author.Link(User.ID.Equals("a")),
+				Post.ID.Set("p"),
+			).Exec(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := client.User.FindUnique(User.ID.Equals("a")).With(
+				User.Posts.Fetch().Take(1),
+			).Exec(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, []PostModel{{
+				InnerPost: InnerPost{
+					ID:       "p",
+					Title:    "a",
+					AuthorID: "a",
+				},
+			}}, actual.Posts())
+		},
+	}, {
+		name: "duplicate fields",
+		run: func(t *testing.T, client *PrismaClient, ctx cx) {
+			create(t, client, ctx)
+
+			actual, err := client.User.FindMany(
+				User.Email.Contains("john"),
+				User.Email.Contains("example"),
+			).Exec(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Len(t, actual, 1)
+
+			actual, err = client.User.FindMany(
+				User.Email.Contains("john"),
+				User.Email.Contains("nobody"),
+			).Exec(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Len(t, actual, 0)
+		},
+	}, {
+		name: "not found",
+		run: func(t *testing.T, client *PrismaClient, ctx cx) {
+			_, err := client.User.FindUnique(User.ID.Equals("404")).Exec(ctx)
+			assert.ErrorIs(t, err, ErrNotFound)
+		},
+	}, {
+		name: "transaction",
+		run: func(t *testing.T, client *PrismaClient, ctx cx) {
+			create(t, client, ctx)
+
+			update := client.User.FindUnique(User.ID.Equals("a")).Update(
+				User.Name.Set("Jane"),
+			).Tx()
+			count := client.User.FindMany().Delete().Tx()
+
+			if err := client.Prisma.Transaction(update, count).Exec(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, "Jane", *update.Result().InnerUser.Name)
+			assert.Equal(t, 1, count.Result().Count)
+		},
+	}, {
+		name: "raw query",
+		run: func(t *testing.T, client *PrismaClient, ctx cx) {
+			create(t, client, ctx)
+
+			var actual []struct {
+				ID string `json:"_id"`
+			}
+			if err := client.Prisma.QueryRaw(`SELECT "_id" FROM "User"`).Exec(ctx, &actual); err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, "a", actual[0].ID)
+		},
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			test.RunSerial(t, []test.Database{test.PostgreSQL}, func(t *testing.T, db test.Database, ctx context.Context) {
+				client := NewClient(WithEngineProtocol(EngineProtocolJSON))
+				mockDBName := test.Start(t, db, client.Engine, nil)
+				defer test.End(t, db, client.Engine, mockDBName)
+				tt.run(t, client, context.Background())
+			})
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/steebchen/prisma-client-go/test"
+)
+
+type cx = context.Context
+type Func func(t *testing.T, client *PrismaClient, ctx cx)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		run  Func
+	}{{
+		name: "metrics",
+		run: func(t *testing.T, client *PrismaClient, ctx cx) {
+			if _, err := client.User.FindMany().Exec(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			m, err := client.Prisma.Metrics(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			queries, ok := m.Counter("prisma_client_queries_total")
+			assert.True(t, ok)
+			assert.GreaterOrEqual(t, queries.Value, int64(1))
+
+			_, ok = m.Gauge("prisma_pool_connections_open")
+			assert.True(t, ok)
+
+			_, ok = m.Histogram("prisma_client_queries_duration_histogram_ms")
+			assert.True(t, ok)
+		},
+	}, {
+		name: "prometheus",
+		run: func(t *testing.T, client *PrismaClient, ctx cx) {
+			text, err := client.Prisma.MetricsPrometheus(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Contains(t, text, "prisma_pool_connections_open")
+		},
+	}, {
+		name: "handler",
+		run: func(t *testing.T, client *PrismaClient, ctx cx) {
+			rec := httptest.NewRecorder()
+			client.Prisma.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+			assert.Equal(t, 200, rec.Code)
+			assert.Contains(t, rec.Body.String(), "prisma_pool_connections_open")
+		},
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			test.RunSerial(t, []test.Database{test.PostgreSQL}, func(t *testing.T, db test.Database, ctx context.Context) {
+				client := NewClient()
+				mockDBName := test.Start(t, db, client.Engine, nil)
+				defer test.End(t, db, client.Engine, mockDBName)
+				tt.run(t, client, context.Background())
+			})
+		})
+	}
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -118,3 +119,108 @@ func TestTransaction(t *testing.T) {
 		})
 	}
 }
+
+func TestTransactionOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		before []string
+		run    Func
+	}{{
+		name: "serializable batch",
+		run: func(t *testing.T, client *PrismaClient, ctx cx) {
+			createUser := client.User.CreateOne(
+				User.Email.Set("a"),
+				User.ID.Set("a"),
+			).Tx()
+
+			err := client.Prisma.Transaction(createUser).Exec(
+				ctx,
+				WithTransactionIsolationLevel(TransactionIsolationLevelSerializable),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			massert.Equal(t, "a", createUser.Result().ID)
+		},
+	}, {
+		name: "batch with timeout is rejected",
+		run: func(t *testing.T, client *PrismaClient, ctx cx) {
+			createUser := client.User.CreateOne(
+				User.Email.Set("a"),
+				User.ID.Set("a"),
+			).Tx()
+
+			// max wait and timeout are only supported by interactive transactions
+			err := client.Prisma.Transaction(createUser).Exec(
+				ctx,
+				WithTransactionMaxWait(5*time.Second),
+				WithTransactionTimeout(10*time.Second),
+			)
+			massert.Error(t, err)
+
+			_, err = client.User.FindUnique(User.ID.Equals("a")).Exec(ctx)
+			massert.ErrorIs(t, err, ErrNotFound)
+		},
+	}, {
+		name: "batch",
+		// language=GraphQL
+		before: []string{`
+			mutation {
+				result: createOneUser(data: {
+					id: "exists",
+					email: "email",
+				}) {
+					id
+				}
+			}
+		`},
+		run: func(t *testing.T, client *PrismaClient, ctx cx) {
+			// this will fail, but doesn't affect the other queries
+			updateMissing := client.User.FindUnique(
+				User.ID.Equals("does-not-exist"),
+			).Update(
+				User.Email.Set("foo"),
+			).Tx()
+
+			updateExisting := client.User.FindUnique(
+				User.ID.Equals("exists"),
+			).Update(
+				User.Email.Set("new"),
+			).Tx()
+
+			createUser := client.User.CreateOne(
+				User.Email.Set("a"),
+				User.ID.Set("a"),
+			).Tx()
+
+			if err := client.Prisma.Batch(updateMissing, updateExisting, createUser).Exec(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			assert.ErrorIs(t, updateMissing.Err(), ErrNotFound)
+			assert.Nil(t, updateMissing.Result())
+
+			assert.NoError(t, updateExisting.Err())
+			massert.Equal(t, "new", updateExisting.Result().Email)
+
+			assert.NoError(t, createUser.Err())
+			massert.Equal(t, "a", createUser.Result().ID)
+		},
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			// MongoDB does not support isolation levels
+			dbs := []test.Database{test.MySQL, test.PostgreSQL, test.SQLite}
+			test.RunSerial(t, dbs, func(t *testing.T, db test.Database, ctx context.Context) {
+				client := NewClient()
+				mockDBName := test.Start(t, db, client.Engine, tt.before)
+				defer test.End(t, db, client.Engine, mockDBName)
+				tt.run(t, client, context.Background())
+			})
+		})
+	}
+}
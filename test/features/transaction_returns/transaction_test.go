@@ -199,13 +199,11 @@ func TestTransactionReturns(t *testing.T) {
 			err := client.Prisma.Transaction(aOp, bOp).Exec(ctx)
 			assert.Errorf(t, err, "should error")
 
-			assert.Panics(t, func() {
-				aOp.Result()
-			})
+			assert.Nil(t, aOp.Result())
+			assert.Error(t, aOp.Err())
 
-			assert.Panics(t, func() {
-				bOp.Result()
-			})
+			assert.Nil(t, bOp.Result())
+			assert.Error(t, bOp.Err())
 
 			// make sure the existing record wasn't touched
 
@@ -44,3 +44,31 @@ func TestConfig(t *testing.T) {
 	// 	t.Fatal(err)
 	// }
 }
+
+func TestConfigEngineURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		option func(*PrismaConfig)
+	}{{
+		name: "tenants",
+		option: WithTenants(func(tenant string) (string, error) {
+			return "file:" + tenant + ".sqlite", nil
+		}, 0),
+	}, {
+		name:   "read replicas",
+		option: WithReadReplicas("file:replica.sqlite"),
+	}}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				massert.Equal(t, "WithEngineURL can't be combined with WithTenants or WithReadReplicas", recover())
+			}()
+
+			NewClient(WithEngineURL("http://127.0.0.1:4466"), tt.option)
+			t.Fatal("expected NewClient to panic")
+		})
+	}
+}
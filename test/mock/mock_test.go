@@ -2,8 +2,11 @@ package mock
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 
+	"github.com/steebchen/prisma-client-go/engine/mock"
 	"github.com/steebchen/prisma-client-go/test/helpers/massert"
 )
 
@@ -86,3 +89,240 @@ func TestMockError(t *testing.T) {
 	massert.Equal(t, expectedErr, err)
 	massert.Equal(t, true, actual == nil)
 }
+
+func TestMockTransaction(t *testing.T) {
+	do := func(ctx context.Context, client *PrismaClient) (*UserModel, error) {
+		a := client.User.CreateOne(User.ID.Set("a"), User.Name.Set("a")).Tx()
+		b := client.User.CreateOne(User.ID.Set("b"), User.Name.Set("b")).Tx()
+		if err := client.Prisma.Transaction(a, b).Exec(ctx); err != nil {
+			return nil, err
+		}
+		return b.Result(), nil
+	}
+
+	expected := UserModel{
+		InnerUser: InnerUser{
+			ID:   "b",
+			Name: "b",
+		},
+	}
+
+	client, mock, ensure := NewMock()
+	defer ensure(t)
+	mock.ExpectTransaction(
+		mock.User.Expect(
+			client.User.CreateOne(User.ID.Set("a"), User.Name.Set("a")),
+		).Returns(UserModel{InnerUser: InnerUser{ID: "a", Name: "a"}}),
+		mock.User.Expect(
+			client.User.CreateOne(User.ID.Set("b"), User.Name.Set("b")),
+		).Returns(expected),
+	)
+
+	actual, err := do(context.Background(), client)
+	massert.Equal(t, nil, err)
+	massert.Equal(t, &expected, actual)
+}
+
+func TestMockTransactionError(t *testing.T) {
+	expectedErr := errors.New("unique constraint failed")
+
+	client, mock, ensure := NewMock()
+	defer ensure(t)
+	mock.ExpectTransaction(
+		mock.User.Expect(
+			client.User.CreateOne(User.ID.Set("a"), User.Name.Set("a")),
+		).Returns(UserModel{InnerUser: InnerUser{ID: "a", Name: "a"}}),
+		mock.User.Expect(
+			client.User.CreateOne(User.ID.Set("b"), User.Name.Set("b")),
+		).Errors(expectedErr),
+	)
+
+	a := client.User.CreateOne(User.ID.Set("a"), User.Name.Set("a")).Tx()
+	b := client.User.CreateOne(User.ID.Set("b"), User.Name.Set("b")).Tx()
+	err := client.Prisma.Transaction(a, b).Exec(context.Background())
+
+	// the whole transaction fails, so no results are returned
+	massert.Equal(t, true, errors.Is(err, expectedErr))
+	massert.Equal(t, true, a.Result() == nil)
+	massert.Equal(t, true, b.Result() == nil)
+}
+
+func TestMockBatch(t *testing.T) {
+	expected := UserModel{
+		InnerUser: InnerUser{
+			ID:   "a",
+			Name: "a",
+		},
+	}
+
+	client, mock, ensure := NewMock()
+	defer ensure(t)
+	mock.User.Expect(
+		client.User.CreateOne(User.ID.Set("a"), User.Name.Set("a")),
+	).Returns(expected)
+	mock.User.Expect(
+		client.User.CreateOne(User.ID.Set("b"), User.Name.Set("b")),
+	).Errors(errors.New("unique constraint failed"))
+
+	a := client.User.CreateOne(User.ID.Set("a"), User.Name.Set("a")).Tx()
+	b := client.User.CreateOne(User.ID.Set("b"), User.Name.Set("b")).Tx()
+	err := client.Prisma.Batch(a, b).Exec(context.Background())
+
+	// queries of a batch without a transaction fail on their own
+	massert.Equal(t, nil, err)
+	massert.Equal(t, &expected, a.Result())
+	massert.Equal(t, nil, a.Err())
+	massert.Equal(t, true, b.Err() != nil)
+}
+
+func TestMockBatchError(t *testing.T) {
+	client, mock, ensure := NewMock()
+	defer ensure(t)
+	mock.User.Expect(
+		client.User.FindUnique(User.ID.Equals("a")).Update(User.Name.Set("b")),
+	).Errors(ErrNotFound)
+
+	a := client.User.FindUnique(User.ID.Equals("a")).Update(User.Name.Set("b")).Tx()
+	err := client.Prisma.Batch(a).Exec(context.Background())
+
+	// the typed error is returned for the query of the batch
+	massert.Equal(t, nil, err)
+	massert.Equal(t, true, errors.Is(a.Err(), ErrNotFound))
+	massert.Equal(t, true, a.Result() == nil)
+}
+
+func TestMockMatchers(t *testing.T) {
+	expected := []UserModel{{
+		InnerUser: InnerUser{
+			ID:   "123",
+			Name: "foo",
+		},
+	}}
+
+	client, m, ensure := NewMock()
+	defer ensure(t)
+	m.User.Expect(
+		client.User.FindMany(User.Name.Equals("")),
+	).Match(User.Name.Field(), func(v interface{}) bool {
+		return strings.HasPrefix(v.(string), "f")
+	}).IgnoreSelection().ReturnsMany(expected)
+	m.User.Expect(
+		client.User.FindUnique(User.ID.Equals("")),
+	).AnyValue(User.ID.Field()).Errors(ErrNotFound)
+
+	actual, err := client.User.FindMany(User.Name.Equals("foo")).Select(User.ID.Field()).Exec(context.Background())
+	massert.Equal(t, nil, err)
+	massert.Equal(t, expected, actual)
+
+	_, err = client.User.FindUnique(User.ID.Equals("456")).Exec(context.Background())
+	massert.Equal(t, ErrNotFound, err)
+}
+
+func TestMockTimes(t *testing.T) {
+	// unexpected calls are reported by ensure, so they are checked manually
+	client, m, _ := NewMock()
+	m.User.Expect(
+		client.User.FindUnique(User.ID.Equals("123")),
+	).Errors(ErrNotFound).Times(2)
+	m.User.Expect(
+		client.User.FindUnique(User.ID.Equals("456")),
+	).Errors(ErrNotFound).AnyTimes()
+
+	for i := 0; i < 2; i++ {
+		_, err := client.User.FindUnique(User.ID.Equals("123")).Exec(context.Background())
+		massert.Equal(t, ErrNotFound, err)
+	}
+
+	_, err := client.User.FindUnique(User.ID.Equals("123")).Exec(context.Background())
+	massert.Equal(t, true, errors.Is(err, mock.ErrUnexpectedQuery))
+	massert.Equal(t, 1, len(m.Engine.Unexpected()))
+}
+
+func TestMockInOrder(t *testing.T) {
+	// unexpected calls are reported by ensure, so they are checked manually
+	client, m, _ := NewMock()
+	m.InOrder(
+		m.User.Expect(
+			client.User.FindUnique(User.ID.Equals("123")),
+		).Errors(ErrNotFound),
+		m.User.Expect(
+			client.User.FindUnique(User.ID.Equals("456")),
+		).Errors(ErrNotFound),
+	)
+
+	_, err := client.User.FindUnique(User.ID.Equals("456")).Exec(context.Background())
+	massert.Equal(t, true, errors.Is(err, mock.ErrUnexpectedQuery))
+
+	_, err = client.User.FindUnique(User.ID.Equals("123")).Exec(context.Background())
+	massert.Equal(t, ErrNotFound, err)
+	_, err = client.User.FindUnique(User.ID.Equals("456")).Exec(context.Background())
+	massert.Equal(t, ErrNotFound, err)
+	massert.Equal(t, 1, len(m.Engine.Unexpected()))
+}
+
+func TestMockUnexpectedQuery(t *testing.T) {
+	client, m, _ := NewMock()
+	m.User.Expect(
+		client.User.FindUnique(User.ID.Equals("123")),
+	).Errors(ErrNotFound)
+
+	_, err := client.User.FindUnique(User.ID.Equals("456")).Exec(context.Background())
+	massert.Equal(t, true, errors.Is(err, mock.ErrUnexpectedQuery))
+	massert.Equal(t, true, strings.Contains(err.Error(), `where.id: expected "123", got "456"`))
+}
+
+func TestMockQueryRaw(t *testing.T) {
+	type row struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	client, mock, ensure := NewMock()
+	defer ensure(t)
+
+	expected := []row{{ID: "a", Name: "a"}, {ID: "b", Name: "b"}}
+	mock.Prisma.ExpectQueryRaw("SELECT * FROM User WHERE name = ? AND id > ?", "a", 5).Returns(expected)
+
+	var actual []row
+	err := client.Prisma.QueryRaw("SELECT * FROM User WHERE name = ? AND id > ?", "a", 5).Exec(context.Background(), &actual)
+	massert.Equal(t, nil, err)
+	massert.Equal(t, expected, actual)
+}
+
+func TestMockQueryRawParameters(t *testing.T) {
+	client, m, _ := NewMock()
+	m.Prisma.ExpectQueryRaw("SELECT * FROM User WHERE id = ?", "a").Returns([]UserModel{})
+
+	var actual []UserModel
+	err := client.Prisma.QueryRaw("SELECT * FROM User WHERE id = ?", "b").Exec(context.Background(), &actual)
+	massert.Equal(t, true, errors.Is(err, mock.ErrUnexpectedQuery))
+
+	client, m, ensure := NewMock()
+	defer ensure(t)
+	m.Prisma.ExpectQueryRaw("SELECT * FROM User WHERE id = ?").AnyParameters().Returns([]UserModel{})
+
+	err = client.Prisma.QueryRaw("SELECT * FROM User WHERE id = ?", "b").Exec(context.Background(), &actual)
+	massert.Equal(t, nil, err)
+}
+
+func TestMockExecuteRaw(t *testing.T) {
+	client, mock, ensure := NewMock()
+	defer ensure(t)
+
+	mock.Prisma.ExpectExecuteRaw("DELETE FROM User WHERE name = ?", "a").Returns(BatchResult{Count: 2})
+
+	result, err := client.Prisma.ExecuteRaw("DELETE FROM User WHERE name = ?", "a").Exec(context.Background())
+	massert.Equal(t, nil, err)
+	massert.Equal(t, &BatchResult{Count: 2}, result)
+}
+
+func TestMockExecuteRawError(t *testing.T) {
+	client, mock, ensure := NewMock()
+	defer ensure(t)
+
+	expected := errors.New("constraint failed")
+	mock.Prisma.ExpectExecuteRaw("DELETE FROM User").Errors(expected)
+
+	_, err := client.Prisma.ExecuteRaw("DELETE FROM User").Exec(context.Background())
+	massert.Equal(t, true, errors.Is(err, expected))
+}